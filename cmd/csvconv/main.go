@@ -0,0 +1,172 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+// Csvconv converts CSV files from one dialect to another, one record at a
+// time. For example, to convert a MySQL dump to RFC 4180 CSV:
+//
+//	csvconv -output-fields-terminated-by , -output-double-quote -output-lines-terminated-by '\r\n' dump.tsv
+//
+// The input dialect is given by flags prefixed with "input-", and the output
+// dialect by flags prefixed with "output-". See the dialect package for all
+// dialect flags, or run csvconv -help. Like in the dialect package, both
+// default to the tab separated format of MySQL's SELECT ... INTO OUTFILE with
+// FIELDS OPTIONALLY ENCLOSED BY '"'. Lines starting with # are records, unless
+// -input-comment is set. Input is read from the named file, or from standard
+// input if none or "-" is given.
+//
+// By default, conversion stops at the first malformed record. With
+// -skip-malformed, malformed records are reported and skipped instead.
+//
+// Exit status is 0 on success, 1 on errors such as I/O errors, 2 on invalid
+// flags and 3 if the input is malformed, even if malformed records were
+// skipped.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/dialect"
+)
+
+// Exit statuses.
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitMalformed
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fset := flag.NewFlagSet("csvconv", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	input := dialect.FromFlagSetWithPrefix(fset, "input-")
+	output := dialect.FromFlagSetWithPrefix(fset, "output-")
+	outputPath := fset.String("output", "", "file to write to; defaults to standard output")
+	bom := fset.Bool("bom", false, "write a byte order mark; requires utf-8 or utf-16le output")
+	fieldCount := fset.Int("field-count", 0, "number of fields every record must have; 0 means any")
+	skipMalformed := fset.Bool("skip-malformed", false, "skip malformed records instead of stopping")
+	deadLetter := fset.String("dead-letter", "", "file to write the text of skipped records to; implies -skip-malformed")
+	maxErrors := fset.Int("max-errors", 0, "stop after skipping this many malformed records; 0 means no limit")
+	fset.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: csvconv [flags] [file]")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fset.NArg() > 1 {
+		fset.Usage()
+		return exitUsage
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintln(stderr, "csvconv:", err)
+		return exitError
+	}
+	invalid := func(err error) int {
+		fmt.Fprintln(stderr, "csvconv:", err)
+		return exitUsage
+	}
+	inDialect, err := input.Dialect()
+	if err != nil {
+		return invalid(err)
+	}
+	outDialect, err := output.Dialect()
+	if err != nil {
+		return invalid(err)
+	}
+	byteOrderMark := csv.NoByteOrderMark
+	if *bom {
+		switch outDialect.Encoding {
+		case csv.UTF8:
+			byteOrderMark = csv.UTF8ByteOrderMark
+		case csv.UTF16LE:
+			byteOrderMark = csv.UTF16LEByteOrderMark
+		default:
+			return invalid(errors.New("-bom requires utf-8 or utf-16le output."))
+		}
+	}
+
+	in := stdin
+	if path := fset.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	out := stdout
+	if *outputPath != "" {
+		f, err := os.Create(*outputPath)
+		if err != nil {
+			return fail(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	r := csv.NewDialectReader(bufio.NewReader(in), *inDialect)
+	r.FieldCount = *fieldCount
+	r.StrictQuotes = true
+	skipped := 0
+	if *skipMalformed || *deadLetter != "" {
+		r.MaxErrors = *maxErrors
+		r.ErrorHandler = func(text []byte, err *csv.ParseError) {
+			skipped++
+			fmt.Fprintln(stderr, "csvconv: skipping record:", err)
+		}
+		if *deadLetter != "" {
+			f, err := os.Create(*deadLetter)
+			if err != nil {
+				return fail(err)
+			}
+			defer f.Close()
+			r.DeadLetter = f
+		}
+	}
+
+	w := csv.NewDialectWriter(out, *outDialect)
+	w.ByteOrderMark = byteOrderMark
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			w.Flush()
+			fmt.Fprintln(stderr, "csvconv:", err)
+			return exitMalformed
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if err := w.Write(record); err != nil {
+			return fail(err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fail(err)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(stderr, "csvconv: skipped %d malformed records\n", skipped)
+		return exitMalformed
+	}
+	return exitOK
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args     []string
+		input    string
+		expected string
+		status   int
+	}{
+		{
+			[]string{"-output-fields-terminated-by", ",", "-output-double-quote", "-output-lines-terminated-by", `\r\n`},
+			"a\t\"b\\\"c\"\n1,5\t\n",
+			"a,\"b\"\"c\"\r\n\"1,5\",\r\n",
+			exitOK,
+		},
+		{
+			[]string{"-input-fields-terminated-by", ",", "-input-double-quote", "-input-lines-terminated-by", `\r\n`, "-output-quoting", "all"},
+			"a,b\r\n1,2\r\n",
+			"\"a\"\t\"b\"\n\"1\"\t\"2\"\n",
+			exitOK,
+		},
+		{
+			[]string{"-output-fields-terminated-by", ",", "-output-double-quote"},
+			"a\tb\n#x\ty\n\"q\tr\"\tz\n",
+			"a,b\n#x,y\nq\tr,z\n",
+			exitOK,
+		},
+		{
+			[]string{"-input-comment", "#"},
+			"a\tb\n#x\ty\n",
+			"a\tb\n",
+			exitOK,
+		},
+		{
+			[]string{"-output-encoding", "utf-16le", "-bom"},
+			"å\n",
+			"\xff\xfe\xe5\x00\n\x00",
+			exitOK,
+		},
+		{
+			[]string{"-output-encoding", "latin1", "-bom"},
+			"a\n",
+			"",
+			exitUsage,
+		},
+		{
+			nil,
+			"a\tb\n\"c\td\n",
+			"a\tb\n",
+			exitMalformed,
+		},
+		{
+			[]string{"-field-count", "2", "-skip-malformed"},
+			"a\tb\nc\nd\te\n",
+			"a\tb\nd\te\n",
+			exitMalformed,
+		},
+		{
+			[]string{"-input-quoting", "sometimes"},
+			"a\n",
+			"",
+			exitUsage,
+		},
+		{
+			[]string{"-output-fields-terminated-by", `"`},
+			"a\n",
+			"",
+			exitUsage,
+		},
+		{
+			[]string{"-no-such-flag"},
+			"a\n",
+			"",
+			exitUsage,
+		},
+		{
+			[]string{"a.csv", "b.csv"},
+			"a\n",
+			"",
+			exitUsage,
+		},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, strings.NewReader(test.input), &stdout, &stderr)
+		if status != test.status || stdout.String() != test.expected {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q\n%s", test.args, status, stdout.String(), test.status, test.expected, stderr.String())
+		}
+	}
+}
+
+func TestConvertFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.tsv")
+	output := filepath.Join(dir, "out.csv")
+	deadLetter := filepath.Join(dir, "rejected.tsv")
+	if err := os.WriteFile(input, []byte("a\tb\nc\nd\te\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-field-count", "2", "-dead-letter", deadLetter, "-output", output, "-output-fields-terminated-by", ",", input}
+	if status := run(args, nil, &stdout, &stderr); status != exitMalformed {
+		t.Error("Unexpected status:", status, stderr.String())
+	}
+	if expected := "csvconv: skipped 1 malformed records\n"; !strings.HasSuffix(stderr.String(), expected) {
+		t.Error("Unexpected output:", stderr.String(), "Expected:", expected)
+	}
+	if b, _ := os.ReadFile(output); string(b) != "a,b\nd,e\n" {
+		t.Error("Unexpected output:", string(b))
+	}
+	if b, _ := os.ReadFile(deadLetter); string(b) != "c\n" {
+		t.Error("Unexpected dead letter:", string(b))
+	}
+
+	stderr.Reset()
+	if status := run([]string{filepath.Join(dir, "missing.tsv")}, nil, &stdout, &stderr); status != exitError {
+		t.Error("Unexpected status:", status, stderr.String())
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A columnSpec is an element of a column list, selecting one or more columns.
+type columnSpec struct {
+	// Set for a single column given by a name.
+	name string
+	// Set for the columns whose names match a regular expression.
+	pattern *regexp.Regexp
+	// One-based first and last column of a range. Zero if open. Equal for a
+	// single column given by an index.
+	from, to int
+	isRange  bool
+	// New name of the column, if renamed.
+	rename string
+}
+
+var rangePattern = regexp.MustCompile(`^([0-9]*)-([0-9]*)$`)
+
+// Parses a comma separated list of columns. Each element is a one-based index
+// such as "3", a range such as "2-4", "5-" or "-3", a regular expression
+// matching names such as "/^addr_/", or a name. Ranges where the first index
+// is greater than the last one select columns in reverse order. If
+// allowRename is true, single columns may be followed by a colon and a new
+// name. Commas, colons and backslashes in names are escaped by a backslash,
+// and a name starting with a backslash is never taken for an index or range.
+func parseColumns(list string, allowRename bool) ([]columnSpec, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var specs []columnSpec
+	for _, element := range splitEscaped(list, ',') {
+		parts := splitEscaped(element, ':')
+		if len(parts) > 2 || len(parts) == 2 && !allowRename {
+			return nil, fmt.Errorf("invalid column %q.", unescape(element))
+		}
+		spec, err := parseColumn(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == 2 {
+			if spec.pattern != nil || spec.isRange {
+				return nil, fmt.Errorf("can't rename several columns in %q.", unescape(element))
+			}
+			spec.rename = unescape(parts[1])
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func parseColumn(s string) (columnSpec, error) {
+	if s == "" {
+		return columnSpec{}, fmt.Errorf("empty column.")
+	}
+	if len(s) >= 2 && s[0] == '/' && s[len(s)-1] == '/' {
+		pattern, err := regexp.Compile(unescape(s[1 : len(s)-1]))
+		if err != nil {
+			return columnSpec{}, err
+		}
+		return columnSpec{pattern: pattern}, nil
+	}
+	if m := rangePattern.FindStringSubmatch(s); m != nil && s != "-" {
+		spec := columnSpec{isRange: true}
+		spec.from, _ = strconv.Atoi(m[1])
+		spec.to, _ = strconv.Atoi(m[2])
+		if m[1] != "" && spec.from < 1 || m[2] != "" && spec.to < 1 {
+			return columnSpec{}, fmt.Errorf("column indices start at 1, got %q.", s)
+		}
+		return spec, nil
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		if i < 1 {
+			return columnSpec{}, fmt.Errorf("column indices start at 1, got %d.", i)
+		}
+		return columnSpec{from: i, to: i}, nil
+	}
+	return columnSpec{name: unescape(s)}, nil
+}
+
+// Splits s at every sep that isn't escaped by a backslash. The parts are
+// still escaped.
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Returns the zero-based columns a spec selects in a record of n columns. If
+// header is non-nil, names and patterns are looked up in it.
+func (s columnSpec) resolve(header []string, n int) ([]int, error) {
+	switch {
+	case s.pattern != nil || s.name != "":
+		if header == nil {
+			return nil, fmt.Errorf("column names require a header.")
+		}
+		var columns []int
+		for i, name := range header {
+			if s.pattern != nil && s.pattern.MatchString(name) || s.pattern == nil && name == s.name {
+				columns = append(columns, i)
+				if s.pattern == nil {
+					break
+				}
+			}
+		}
+		if columns == nil && s.pattern != nil {
+			return nil, fmt.Errorf("no column matches /%s/.", s.pattern)
+		}
+		if columns == nil {
+			return nil, fmt.Errorf("no column named %q.", s.name)
+		}
+		return columns, nil
+	case !s.isRange:
+		if header != nil && s.from > n {
+			return nil, fmt.Errorf("column %d is out of range, the header has %d columns.", s.from, n)
+		}
+		return []int{s.from - 1}, nil
+	}
+	from, to := s.from, s.to
+	if from == 0 {
+		from = 1
+	}
+	if to == 0 {
+		if from > n {
+			return nil, nil
+		}
+		to = n
+	}
+	var columns []int
+	if from <= to {
+		for i := from; i <= to && i <= n; i++ {
+			columns = append(columns, i-1)
+		}
+	} else {
+		for i := from; i >= to; i-- {
+			if i <= n {
+				columns = append(columns, i-1)
+			}
+		}
+	}
+	return columns, nil
+}
+
+// A selection of columns to output.
+type selection struct {
+	fields []columnSpec
+	drop   []columnSpec
+}
+
+// Returns the zero-based columns to output for a record of n columns, and
+// their names if header is non-nil.
+func (s selection) resolve(header []string, n int) ([]int, []string, error) {
+	var columns []int
+	var names []string
+	if s.fields == nil {
+		for i := 0; i < n; i++ {
+			columns = append(columns, i)
+		}
+	}
+	for _, spec := range s.fields {
+		resolved, err := spec.resolve(header, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, i := range resolved {
+			columns = append(columns, i)
+			if spec.rename != "" {
+				names = append(names, spec.rename)
+			} else {
+				names = append(names, field(header, i))
+			}
+		}
+	}
+	if names == nil && header != nil {
+		for _, i := range columns {
+			names = append(names, field(header, i))
+		}
+	}
+
+	dropped := make(map[int]bool)
+	for _, spec := range s.drop {
+		resolved, err := spec.resolve(header, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, i := range resolved {
+			dropped[i] = true
+		}
+	}
+	if len(dropped) == 0 {
+		return columns, names, nil
+	}
+	var keptColumns []int
+	var keptNames []string
+	for j, i := range columns {
+		if !dropped[i] {
+			keptColumns = append(keptColumns, i)
+			if names != nil {
+				keptNames = append(keptNames, names[j])
+			}
+		}
+	}
+	return keptColumns, keptNames, nil
+}
+
+// Returns a field of a record, or an empty string if the record is too short.
+func field(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	return ""
+}
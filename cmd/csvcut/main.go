@@ -0,0 +1,153 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+// Csvcut selects, drops, reorders and renames the columns of a CSV file:
+//
+//	csvcut -fields 'id,name:full_name,/^address_/,7-' customers.tsv
+//	csvcut -drop 'password,3-5' users.tsv
+//
+// A column list is a comma separated list of one-based indices such as 3,
+// ranges such as 2-4, 5- or -3, regular expressions matching header names
+// such as /^address_/, and header names. Columns are output in the order
+// they are listed. A range such as 4-2 selects columns in reverse order.
+// Columns of -fields may be renamed by appending a colon and the new name.
+// Escape commas, colons and backslashes in names with a backslash, and start
+// a name that looks like an index with one.
+//
+// Records are read and written with the same dialect, given using the flags
+// of the dialect package. Run csvcut -help for all flags. Lines starting with
+// # are records, unless -comment is set. Records are streamed, so files of any
+// size can be cut in constant memory. Input is read from the named file, or
+// from standard input if none or "-" is given.
+//
+// Exit status is 0 on success, 1 on errors, 2 on invalid flags or columns that
+// don't match the input, and 3 if the input is malformed.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/dialect"
+)
+
+// Exit statuses.
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitMalformed
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fset := flag.NewFlagSet("csvcut", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	builder := dialect.FromFlagSet(fset)
+	fields := fset.String("fields", "", "columns to output, in order; defaults to all columns")
+	drop := fset.String("drop", "", "columns not to output")
+	header := fset.Bool("header", true, "whether the first record is a header with column names")
+	fset.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: csvcut [flags] [file]")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fset.NArg() > 1 {
+		fset.Usage()
+		return exitUsage
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintln(stderr, "csvcut:", err)
+		return exitError
+	}
+	invalid := func(err error) int {
+		fmt.Fprintln(stderr, "csvcut:", err)
+		return exitUsage
+	}
+	var sel selection
+	var err error
+	if sel.fields, err = parseColumns(*fields, true); err != nil {
+		return invalid(fmt.Errorf("-fields: %v", err))
+	}
+	if sel.drop, err = parseColumns(*drop, false); err != nil {
+		return invalid(fmt.Errorf("-drop: %v", err))
+	}
+	d, err := builder.Dialect()
+	if err != nil {
+		return invalid(err)
+	}
+
+	in := stdin
+	if path := fset.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	r := csv.NewDialectReader(bufio.NewReader(in), *d)
+	r.StrictQuotes = true
+	w := csv.NewDialectWriter(stdout, *d)
+
+	// Columns to output. Resolved once from the header if there is one, and
+	// otherwise for every record since open ranges depend on its length.
+	var columns []int
+	var out []string
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			w.Flush()
+			fmt.Fprintln(stderr, "csvcut:", err)
+			return exitMalformed
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if first && *header {
+			var names []string
+			if columns, names, err = sel.resolve(record, len(record)); err != nil {
+				return invalid(err)
+			}
+			if err := w.Write(names); err != nil {
+				return fail(err)
+			}
+			continue
+		}
+		if !*header {
+			if columns, _, err = sel.resolve(nil, len(record)); err != nil {
+				return invalid(err)
+			}
+		}
+		out = out[:0]
+		for _, i := range columns {
+			out = append(out, field(record, i))
+		}
+		if err := w.Write(out); err != nil {
+			return fail(err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCut(t *testing.T) {
+	t.Parallel()
+
+	input := "id\tname\taddr_street\taddr_city\tpassword\n1\tAnna\tMain St\tLund\tsecret\n2\tBo\n"
+	tests := []struct {
+		args     []string
+		expected string
+		status   int
+	}{
+		{nil, strings.Replace(input, "Bo\n", "Bo\t\t\t\n", 1), exitOK},
+		{
+			[]string{"-fields", "name:full_name,1,/^addr_/"},
+			"full_name\tid\taddr_street\taddr_city\nAnna\t1\tMain St\tLund\nBo\t2\t\t\n",
+			exitOK,
+		},
+		{
+			[]string{"-fields", "3-,-1", "-drop", "password"},
+			"addr_street\taddr_city\tid\nMain St\tLund\t1\n\t\t2\n",
+			exitOK,
+		},
+		{
+			[]string{"-fields", "3-1"},
+			"addr_street\tname\tid\nMain St\tAnna\t1\n\tBo\t2\n",
+			exitOK,
+		},
+		{
+			[]string{"-drop", "/^addr_/,5"},
+			"id\tname\n1\tAnna\n2\tBo\n",
+			exitOK,
+		},
+		{[]string{"-fields", "nickname"}, "", exitUsage},
+		{[]string{"-fields", "/^zip/"}, "", exitUsage},
+		{[]string{"-fields", "6"}, "", exitUsage},
+		{[]string{"-fields", "0"}, "", exitUsage},
+		{[]string{"-fields", "/(/"}, "", exitUsage},
+		{[]string{"-fields", "1-3:x"}, "", exitUsage},
+		{[]string{"-drop", "id:x"}, "", exitUsage},
+		{[]string{"-fields", "id,,name"}, "", exitUsage},
+		{[]string{"-header=false", "-fields", "name"}, "", exitUsage},
+		{[]string{"-fields-terminated-by", `"`}, "", exitUsage},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, strings.NewReader(input), &stdout, &stderr)
+		if status != test.status || stdout.String() != test.expected {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q\n%s", test.args, status, stdout.String(), test.status, test.expected, stderr.String())
+		}
+	}
+}
+
+func TestCutWithoutHeader(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	input := "a\tb\tc\nd\te\tf\tg\nh\n"
+	status := run([]string{"-header=false", "-fields", "2-,1"}, strings.NewReader(input), &stdout, &stderr)
+	if expected := "b\tc\ta\ne\tf\tg\td\nh\n"; status != exitOK || stdout.String() != expected {
+		t.Error("Unexpected output:", status, stdout.String(), "Expected:", expected, stderr.String())
+	}
+}
+
+func TestCutComments(t *testing.T) {
+	t.Parallel()
+
+	input := "#id\tname\n#1\tAnna\n2\tBo\n"
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-fields", "name"}, "name\nAnna\nBo\n"},
+		{[]string{"-comment", "#", "-header=false", "-fields", "2"}, "Bo\n"},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, strings.NewReader(input), &stdout, &stderr)
+		if status != exitOK || stdout.String() != test.expected {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q\n%s", test.args, status, stdout.String(), exitOK, test.expected, stderr.String())
+		}
+	}
+}
+
+func TestCutEscapedNames(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	input := "2\ta,b\tc:d\n1\t2\t3\n"
+	status := run([]string{"-fields", `\2,c\:d:e\,f,a\,b`}, strings.NewReader(input), &stdout, &stderr)
+	if expected := "2\te,f\ta,b\n1\t3\t2\n"; status != exitOK || stdout.String() != expected {
+		t.Error("Unexpected output:", status, stdout.String(), "Expected:", expected, stderr.String())
+	}
+}
+
+func TestCutMalformed(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-fields", "1"}, strings.NewReader("a\tb\n\"c\n"), &stdout, &stderr)
+	if status != exitMalformed || stdout.String() != "a\n" {
+		t.Error("Unexpected output:", status, stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+
+	"github.com/JensRantil/go-csv/schema"
+)
+
+// Generates a struct type and functions to decode and encode it.
+type generator struct {
+	Package string
+	Type    string
+	// Name of the sample file, for the header comment.
+	Source string
+	Fields []schema.GoField
+}
+
+// Go expressions parsing a string and formatting a value of each type. The
+// verb is replaced by the operand.
+var (
+	parseExprs = map[string]string{
+		"int64":     "strconv.ParseInt(%s, 10, 64)",
+		"float64":   "strconv.ParseFloat(%s, 64)",
+		"bool":      "strconv.ParseBool(%s)",
+		"time.Time": "time.Parse(%[2]q, %[1]s)",
+	}
+	formatExprs = map[string]string{
+		"string":    "%s",
+		"int64":     "strconv.FormatInt(%s, 10)",
+		"float64":   "strconv.FormatFloat(%s, 'f', -1, 64)",
+		"bool":      "strconv.FormatBool(%s)",
+		"time.Time": "%s.Format(%[2]q)",
+	}
+)
+
+// Returns an expression of parseExprs or formatExprs for an operand.
+func expr(format, operand, layout string) string {
+	if strings.Contains(format, "%[2]") {
+		return fmt.Sprintf(format, operand, layout)
+	}
+	return fmt.Sprintf(format, operand)
+}
+
+func (g generator) generate(w io.Writer) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by csvgen from %s. DO NOT EDIT.\n\n", g.Source)
+	fmt.Fprintf(&b, "package %s\n\n", g.Package)
+	b.WriteString("import (\n\"fmt\"\n")
+	if g.uses("int64", "float64", "bool") {
+		b.WriteString("\"strconv\"\n")
+	}
+	if g.uses("time.Time") {
+		b.WriteString("\"time\"\n")
+	}
+	b.WriteString(")\n\n")
+
+	fmt.Fprintf(&b, "// %s is a record of %s.\n", g.Type, g.Source)
+	fmt.Fprintf(&b, "type %s struct {\n", g.Type)
+	for _, f := range g.Fields {
+		fmt.Fprintf(&b, "%s %s %s\n", f.Name, f.Type, f.Tag())
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "// %sHeader holds the column names of %s records.\n", g.Type, g.Type)
+	fmt.Fprintf(&b, "var %sHeader = []string{", g.Type)
+	for _, f := range g.Fields {
+		fmt.Fprintf(&b, "%q, ", f.Column)
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "// Decode%[1]s decodes a record into a %[1]s. Empty values of nullable\n// columns are decoded as nil pointers.\n", g.Type)
+	fmt.Fprintf(&b, "func Decode%[1]s(record []string) (%[1]s, error) {\nvar v %[1]s\n", g.Type)
+	fmt.Fprintf(&b, "if len(record) != %d {\nreturn v, fmt.Errorf(\"expected %d fields, got %%d\", len(record))\n}\n", len(g.Fields), len(g.Fields))
+	for i, f := range g.Fields {
+		g.writeDecode(&b, i, f)
+	}
+	b.WriteString("return v, nil\n}\n\n")
+
+	fmt.Fprintf(&b, "// Encode%[1]s encodes a %[1]s as a record. Nil pointers are encoded as\n// empty values.\n", g.Type)
+	fmt.Fprintf(&b, "func Encode%[1]s(v %[1]s) []string {\nrecord := make([]string, %d)\n", g.Type, len(g.Fields))
+	for i, f := range g.Fields {
+		g.writeEncode(&b, i, f)
+	}
+	b.WriteString("return record\n}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// Returns whether any field has one of the types, possibly as a pointer.
+func (g generator) uses(types ...string) bool {
+	for _, f := range g.Fields {
+		for _, t := range types {
+			if strings.TrimPrefix(f.Type, "*") == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g generator) writeDecode(b *bytes.Buffer, i int, f schema.GoField) {
+	value := fmt.Sprintf("record[%d]", i)
+	if f.Type == "string" {
+		fmt.Fprintf(b, "v.%s = %s\n", f.Name, value)
+		return
+	}
+	nullable := strings.HasPrefix(f.Type, "*")
+	if nullable {
+		fmt.Fprintf(b, "if %s != \"\" {\n", value)
+	} else {
+		b.WriteString("{\n")
+	}
+	fmt.Fprintf(b, "x, err := %s\n", expr(parseExprs[strings.TrimPrefix(f.Type, "*")], value, f.Layout))
+	fmt.Fprintf(b, "if err != nil {\nreturn v, fmt.Errorf(\"column %%q: %%w\", %q, err)\n}\n", f.Column)
+	if nullable {
+		fmt.Fprintf(b, "v.%s = &x\n}\n", f.Name)
+	} else {
+		fmt.Fprintf(b, "v.%s = x\n}\n", f.Name)
+	}
+}
+
+func (g generator) writeEncode(b *bytes.Buffer, i int, f schema.GoField) {
+	typ := strings.TrimPrefix(f.Type, "*")
+	if typ == f.Type {
+		fmt.Fprintf(b, "record[%d] = %s\n", i, expr(formatExprs[typ], "v."+f.Name, f.Layout))
+		return
+	}
+	fmt.Fprintf(b, "if v.%s != nil {\n", f.Name)
+	fmt.Fprintf(b, "record[%d] = %s\n}\n", i, expr(formatExprs[typ], "*v."+f.Name, f.Layout))
+}
@@ -0,0 +1,101 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+// Csvgen generates a Go struct for the records of a CSV file, together with
+// functions that decode and encode records without reflection. The types of
+// the fields are inferred from a sample file:
+//
+//	csvgen -type Customer -output customer_csv.go testdata/customers.csv
+//
+// It is meant to be used with go:generate, to keep importers in sync with the
+// format of a file:
+//
+//	//go:generate csvgen -type Customer -output customer_csv.go testdata/customers.csv
+//
+// The dialect of the sample file is given using the flags of the dialect
+// package, such as -fields-terminated-by. Run csvgen -help for all flags.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/dialect"
+	"github.com/JensRantil/go-csv/schema"
+)
+
+var (
+	typeName    = flag.String("type", "", "name of the generated struct type; defaults to the name of the sample file")
+	packageName = flag.String("package", "", "package of the generated code; defaults to $GOPACKAGE or main")
+	output      = flag.String("output", "", "file to write the generated code to; defaults to standard output")
+	sampleSize  = flag.Int("sample", 1000, "number of records to infer types from; 0 means all")
+	header      = flag.Bool("header", true, "whether the first record is a header with column names")
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] sample-file\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	builder := dialect.FromCommandLine()
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(builder, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "csvgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(builder *dialect.DialectBuilder, path string) error {
+	d, err := builder.Dialect()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	profiler := schema.Profiler{SampleSize: *sampleSize, Header: *header}
+	profile, err := profiler.Profile(csv.NewDialectReader(f, *d))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	g := generator{
+		Package: *packageName,
+		Type:    *typeName,
+		Source:  filepath.Base(path),
+		Fields:  profile.GoFields(),
+	}
+	if g.Package == "" {
+		g.Package = os.Getenv("GOPACKAGE")
+	}
+	if g.Package == "" {
+		g.Package = "main"
+	}
+	if g.Type == "" {
+		g.Type = schema.GoName(strings.TrimSuffix(g.Source, filepath.Ext(g.Source)))
+	}
+
+	var b bytes.Buffer
+	if err := g.generate(&b); err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(b.Bytes())
+		return err
+	}
+	return os.WriteFile(*output, b.Bytes(), 0o644)
+}
@@ -0,0 +1,426 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	csv "github.com/JensRantil/go-csv"
+)
+
+// A condition on a record.
+type condition interface {
+	match(record []string) bool
+}
+
+// A value computed from a record.
+type operand interface {
+	value(record []string) string
+}
+
+type (
+	orCondition  struct{ left, right condition }
+	andCondition struct{ left, right condition }
+	notCondition struct{ c condition }
+	// True for values that aren't empty.
+	nonEmptyCondition struct{ o operand }
+	compareCondition  struct {
+		op          string
+		left, right operand
+	}
+	matchCondition struct {
+		o       operand
+		pattern *regexp.Regexp
+		negate  bool
+	}
+
+	literal string
+	// A zero-based column. Values of columns missing from a record are empty.
+	column int
+)
+
+func (c orCondition) match(record []string) bool {
+	return c.left.match(record) || c.right.match(record)
+}
+
+func (c andCondition) match(record []string) bool {
+	return c.left.match(record) && c.right.match(record)
+}
+
+func (c notCondition) match(record []string) bool {
+	return !c.c.match(record)
+}
+
+func (c nonEmptyCondition) match(record []string) bool {
+	return c.o.value(record) != ""
+}
+
+func (c compareCondition) match(record []string) bool {
+	cmp := compareValues(c.left.value(record), c.right.value(record))
+	switch c.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	panic("Unexpected operator.")
+}
+
+func (c matchCondition) match(record []string) bool {
+	return c.pattern.MatchString(c.o.value(record)) != c.negate
+}
+
+func (l literal) value(record []string) string {
+	return string(l)
+}
+
+func (c column) value(record []string) string {
+	if int(c) < len(record) {
+		return record[c]
+	}
+	return ""
+}
+
+// Compares two values as numbers if both are numbers, and as strings
+// otherwise.
+func compareValues(a, b string) int {
+	x, okA := parseNumber(a)
+	y, okB := parseNumber(b)
+	if okA && okB {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// Parses a finite decimal number, ignoring surrounding spaces. Unlike
+// strconv.ParseFloat, it doesn't accept NaN, infinities or hexadecimal
+// numbers, which would otherwise compare surprisingly.
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if !csv.IsNumeric(s) {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenOperator
+	tokenName
+	tokenIndex
+	tokenString
+	tokenNumber
+	tokenRegexp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// Byte offset in the expression, for error messages.
+	pos int
+}
+
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+
+// Splits an expression into tokens. A slash starts a regular expression
+// after a match operator.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[pos:])
+		if unicode.IsSpace(r) {
+			pos += size
+			continue
+		}
+		afterMatch := len(tokens) > 0 && (tokens[len(tokens)-1].text == "=~" || tokens[len(tokens)-1].text == "!~")
+		t := token{pos: pos}
+		switch {
+		case r == '/' && afterMatch:
+			end := closing(expr, pos, '/')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated regular expression at offset %d", pos)
+			}
+			t.kind, t.text = tokenRegexp, strings.ReplaceAll(expr[pos+1:end], `\/`, "/")
+			pos = end + 1
+		case r == '"':
+			end := closing(expr, pos, '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", pos)
+			}
+			text, err := strconv.Unquote(expr[pos : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %v", pos, err)
+			}
+			t.kind, t.text = tokenString, text
+			pos = end + 1
+		case r == '`':
+			end := strings.IndexByte(expr[pos+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated column name at offset %d", pos)
+			}
+			t.kind, t.text = tokenName, expr[pos+1:pos+1+end]
+			pos += end + 2
+		case r == '$':
+			end := pos + 1
+			for end < len(expr) && expr[end] >= '0' && expr[end] <= '9' {
+				end++
+			}
+			if end == pos+1 {
+				return nil, fmt.Errorf("expected a column number after $ at offset %d", pos)
+			}
+			t.kind, t.text = tokenIndex, expr[pos+1:end]
+			pos = end
+		case r >= '0' && r <= '9' || r == '-' || r == '.':
+			end := pos + 1
+			for end < len(expr) && strings.IndexByte("0123456789.eE+-", expr[end]) >= 0 {
+				if (expr[end] == '+' || expr[end] == '-') && expr[end-1] != 'e' && expr[end-1] != 'E' {
+					break
+				}
+				end++
+			}
+			if _, err := strconv.ParseFloat(expr[pos:end], 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", expr[pos:end], pos)
+			}
+			t.kind, t.text = tokenNumber, expr[pos:end]
+			pos = end
+		case unicode.IsLetter(r) || r == '_':
+			end := pos
+			for end < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[end:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					break
+				}
+				end += size
+			}
+			t.kind, t.text = tokenName, expr[pos:end]
+			pos = end
+		default:
+			for _, op := range operators {
+				if strings.HasPrefix(expr[pos:], op) {
+					t.kind, t.text = tokenOperator, op
+					break
+				}
+			}
+			if t.kind != tokenOperator {
+				return nil, fmt.Errorf("unexpected %q at offset %d", r, pos)
+			}
+			pos += len(t.text)
+		}
+		tokens = append(tokens, t)
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+// Returns the offset of the delimiter closing a string or regular expression
+// starting at start, skipping characters escaped by a backslash. Returns -1
+// if there is none.
+func closing(expr string, start int, delim byte) int {
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			i++
+		case delim:
+			return i
+		}
+	}
+	return -1
+}
+
+// Parses expressions using a recursive descent parser.
+type parser struct {
+	tokens []token
+	pos    int
+	// Header of the input, to look up column names. Nil if there is none.
+	header []string
+	// Whether to accept column names without looking them up.
+	syntaxOnly bool
+}
+
+// Parses an expression such as `age > 30 && country == "SE"`. The grammar is:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | comparison
+//	comparison = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) operand
+//	                     | ( "=~" | "!~" ) ( regexp | string ) ]
+//	operand    = name | "`" name "`" | "$" index | string | number
+//
+// An operand on its own is true if it isn't empty. Names refer to columns of
+// the header, and one-based indices to columns of any record.
+func parseExpr(expr string, header []string) (condition, error) {
+	return parse(&parser{header: header}, expr)
+}
+
+// Checks the syntax of an expression without looking up column names, so
+// that it can be done before the header is read.
+func checkExpr(expr string) error {
+	_, err := parse(&parser{syntaxOnly: true}, expr)
+	return err
+}
+
+func parse(p *parser, expr string) (condition, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p.tokens = tokens
+	c, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return c, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// Consumes the next token if it is the operator op.
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+func (p *parser) or() (condition, error) {
+	left, err := p.and()
+	for err == nil && p.accept("||") {
+		var right condition
+		if right, err = p.and(); err == nil {
+			left = orCondition{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) and() (condition, error) {
+	left, err := p.unary()
+	for err == nil && p.accept("&&") {
+		var right condition
+		if right, err = p.unary(); err == nil {
+			left = andCondition{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) unary() (condition, error) {
+	if p.accept("!") {
+		c, err := p.unary()
+		return notCondition{c}, err
+	}
+	if p.accept("(") {
+		c, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.unexpected(p.peek())
+		}
+		return c, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (condition, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return nonEmptyCondition{left}, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return compareCondition{t.text, left, right}, nil
+	case "=~", "!~":
+		p.next()
+		pt := p.next()
+		if pt.kind != tokenRegexp && pt.kind != tokenString {
+			return nil, p.unexpected(pt)
+		}
+		pattern, err := regexp.Compile(pt.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at offset %d: %v", pt.pos, err)
+		}
+		return matchCondition{left, pattern, t.text == "!~"}, nil
+	}
+	return nonEmptyCondition{left}, nil
+}
+
+func (p *parser) operand() (operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString, tokenNumber:
+		return literal(t.text), nil
+	case tokenIndex:
+		i, err := strconv.Atoi(t.text)
+		if err != nil || i < 1 {
+			return nil, fmt.Errorf("invalid column $%s at offset %d", t.text, t.pos)
+		}
+		return column(i - 1), nil
+	case tokenName:
+		if p.syntaxOnly {
+			return column(0), nil
+		}
+		if p.header == nil {
+			return nil, fmt.Errorf("column name %q requires a header", t.text)
+		}
+		for i, name := range p.header {
+			if name == t.text {
+				return column(i), nil
+			}
+		}
+		return nil, fmt.Errorf("no column named %q", t.text)
+	}
+	return nil, p.unexpected(t)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	t.Parallel()
+
+	header := []string{"name", "age", "country", "first name", "note", "score", "limit"}
+	record := []string{"Anna", "31", "SE", "Anna Maria", "", "NaN", "Inf"}
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`age > 30`, true},
+		{`age > 30.5`, true},
+		{`age >= 31 && age <= 31`, true},
+		{`age < 4`, false},
+		{`age == "31.0"`, true},
+		{`name < "Bo"`, true},
+		{`age > 30 && country == "SE"`, true},
+		{`age > 40 || country != "NO"`, true},
+		{`!(age > 30) || country == "NO"`, false},
+		{`name =~ /^an/`, false},
+		{`name =~ /(?i)^an/`, true},
+		{`name !~ "^B"`, true},
+		{`$3 == "SE" && $1 == name`, true},
+		{`$9 == ""`, true},
+		{"`first name` =~ / Maria$/", true},
+		{`note`, false},
+		{`!note && name`, true},
+		{`age > -1e3`, true},
+		{`note =~ /a\/b/ || name == "Anna"`, true},
+		{`score == 5`, false},
+		{`score != 5`, true},
+		{`score == "NaN"`, true},
+		{`limit == "+Inf"`, false},
+	}
+	for _, test := range tests {
+		c, err := parseExpr(test.expr, header)
+		if err != nil {
+			t.Error(test.expr, "Unexpected error:", err)
+			continue
+		}
+		if got := c.match(record); got != test.expected {
+			t.Error(test.expr, "Unexpected output:", got, "Expected:", test.expected)
+		}
+	}
+
+	invalid := []string{
+		``,
+		`age >`,
+		`age > 30 &&`,
+		`(age > 30`,
+		`age > 30)`,
+		`nickname == "x"`,
+		`name =~ /(/`,
+		`name =~ age`,
+		`name == "x`,
+		`name =~ /x`,
+		"`first name",
+		`$0 == "x"`,
+		`$ == "x"`,
+		`age > 1.2.3`,
+		`age # 3`,
+	}
+	for _, expr := range invalid {
+		if _, err := parseExpr(expr, header); err == nil {
+			t.Error(expr, "Expected an error.")
+		}
+	}
+	if _, err := parseExpr(`name == "x"`, nil); err == nil {
+		t.Error("Expected an error for a name without a header.")
+	}
+
+	if err := checkExpr(`nickname == "x" && $2 > 1`); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	for _, expr := range invalid {
+		if expr == `nickname == "x"` {
+			continue
+		}
+		if err := checkExpr(expr); err == nil {
+			t.Error(expr, "Expected an error.")
+		}
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"10", "9", 1},
+		{" 1.5", "1.50 ", 0},
+		{"-1e3", "-999", -1},
+		{"NaN", "NaN", 0},
+		{"NaN", "1", 1},
+		{"inf", "Inf", 1},
+		{"-Infinity", "-1", 1},
+		{"0x10", "16", -1},
+		{"1e400", "2e400", -1},
+	}
+	for _, test := range tests {
+		if c := compareValues(test.a, test.b); c != test.expected {
+			t.Errorf("%q, %q: Unexpected output: %d Expected: %d", test.a, test.b, c, test.expected)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+// Csvgrep prints the records of a CSV file that match an expression:
+//
+//	csvgrep 'age > 30 && country == "SE"' customers.tsv
+//	csvgrep -v -c 'email =~ /@example\.com$/' customers.tsv
+//
+// Expressions compare columns, given by header name or by one-based index
+// such as $3, with strings, numbers and other columns using ==, !=, <, <=, >
+// and >=. Values are compared as numbers if both are decimal numbers, such as
+// 42, -1.5 or 1e3, and as strings otherwise, so NaN and Inf are strings. =~
+// and !~ match a column against a regular expression, written as /regexp/ or
+// as a string. Conditions are combined using &&, || and !, and grouped using
+// parentheses. A column on its own is true if it isn't empty.
+// Quote column names that aren't identifiers with backticks, such as
+// `first name`.
+//
+// Unlike grep, csvgrep reads whole records, so a quoted field spanning several
+// lines is matched as one. Records are read and written with the same dialect,
+// given using the flags of the dialect package. Run csvgrep -help for all
+// flags. Lines starting with # are records, unless -comment is set. Input is
+// read from the named file, or from standard input if none or "-" is given.
+//
+// Like for grep, exit status is 0 if a record matched, 1 if none did and 2 on
+// errors, including invalid flags. It is 3 if the input is malformed.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/dialect"
+)
+
+// Exit statuses.
+const (
+	exitMatch = iota
+	exitNoMatch
+	exitError
+	exitMalformed
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fset := flag.NewFlagSet("csvgrep", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	builder := dialect.FromFlagSet(fset)
+	invert := fset.Bool("v", false, "print records that don't match")
+	count := fset.Bool("c", false, "only print the number of matching records")
+	lineNumbers := fset.Bool("n", false, "prefix records with the line they start on")
+	header := fset.Bool("header", true, "whether the first record is a header with column names; it is always printed")
+	fset.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: csvgrep [flags] expression [file]")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitMatch
+		}
+		return exitError
+	}
+	if fset.NArg() < 1 || fset.NArg() > 2 {
+		fset.Usage()
+		return exitError
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintln(stderr, "csvgrep:", err)
+		return exitError
+	}
+	d, err := builder.Dialect()
+	if err != nil {
+		return fail(err)
+	}
+	// Column names can only be looked up once the header is read, but syntax
+	// errors are reported without reading any input.
+	var cond condition
+	if *header {
+		err = checkExpr(fset.Arg(0))
+	} else {
+		cond, err = parseExpr(fset.Arg(0), nil)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	in := stdin
+	if path := fset.Arg(1); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	r := csv.NewDialectReader(bufio.NewReader(in), *d)
+	r.StrictQuotes = true
+	w := csv.NewDialectWriter(stdout, *d)
+	write := func(line string, record []string) error {
+		if *count {
+			return nil
+		}
+		if *lineNumbers {
+			record = append([]string{line}, record...)
+		}
+		return w.Write(record)
+	}
+
+	matches := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			w.Flush()
+			fmt.Fprintln(stderr, "csvgrep:", err)
+			return exitMalformed
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if cond == nil {
+			if cond, err = parseExpr(fset.Arg(0), record); err != nil {
+				return fail(err)
+			}
+			if err := write("line", record); err != nil {
+				return fail(err)
+			}
+			continue
+		}
+		if cond.match(record) == *invert {
+			continue
+		}
+		matches++
+		if err := write(strconv.Itoa(r.RecordLine()), record); err != nil {
+			return fail(err)
+		}
+	}
+	if *count {
+		fmt.Fprintln(stdout, matches)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fail(err)
+	}
+	if matches == 0 {
+		return exitNoMatch
+	}
+	return exitMatch
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	t.Parallel()
+
+	input := "name\tage\tnote\nAnna\t31\t\"multi\nline\"\nBo\t25\t\nCecilia\t45\tline\n"
+	tests := []struct {
+		args     []string
+		expected string
+		status   int
+	}{
+		{[]string{`age > 30`}, "name\tage\tnote\nAnna\t31\t\"multi\nline\"\nCecilia\t45\tline\n", exitMatch},
+		{[]string{"-v", `age > 30`}, "name\tage\tnote\nBo\t25\t\n", exitMatch},
+		{[]string{"-c", `note =~ /line/`}, "2\n", exitMatch},
+		{[]string{"-n", `note =~ /^multi/`}, "line\tname\tage\tnote\n2\tAnna\t31\t\"multi\nline\"\n", exitMatch},
+		{[]string{"-header=false", "-n", `$2 == 25`}, "4\tBo\t25\t\n", exitMatch},
+		{[]string{`age > 50`}, "name\tage\tnote\n", exitNoMatch},
+		{[]string{"-c", `age > 50`}, "0\n", exitNoMatch},
+		{[]string{`nickname == "x"`}, "", exitError},
+		{[]string{`age >`}, "", exitError},
+		{nil, "", exitError},
+		{[]string{"-no-such-flag", "age"}, "", exitError},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, strings.NewReader(input), &stdout, &stderr)
+		if status != test.status || stdout.String() != test.expected {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q\n%s", test.args, status, stdout.String(), test.status, test.expected, stderr.String())
+		}
+	}
+}
+
+func TestGrepInvalidExpression(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{{`age >`}, {"-header=false", `age`}} {
+		var stdout, stderr bytes.Buffer
+		status := run(args, strings.NewReader(""), &stdout, &stderr)
+		if status != exitError || stdout.String() != "" {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q", args, status, stdout.String(), exitError, "")
+		}
+	}
+}
+
+func TestGrepComments(t *testing.T) {
+	t.Parallel()
+
+	input := "#name\tage\n#x\t40\nBo\t25\n"
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{`age > 30`}, "#name\tage\n#x\t40\n"},
+		{[]string{"-comment", "#", "-header=false", `$2 > 20`}, "Bo\t25\n"},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, strings.NewReader(input), &stdout, &stderr)
+		if status != exitMatch || stdout.String() != test.expected {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q\n%s", test.args, status, stdout.String(), exitMatch, test.expected, stderr.String())
+		}
+	}
+}
+
+func TestGrepMalformed(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-header=false", `$1`}, strings.NewReader("a\n\"b\n"), &stdout, &stderr)
+	if status != exitMalformed || stdout.String() != "a\n" {
+		t.Error("Unexpected output:", status, stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	csv "github.com/JensRantil/go-csv"
+)
+
+// Names of the checks.
+const (
+	checkBOM             = "bom"
+	checkEncoding        = "invalid-encoding"
+	checkQuote           = "quote"
+	checkBareQuote       = "bare-quote"
+	checkParse           = "parse-error"
+	checkFieldCount      = "field-count"
+	checkLineEnding      = "line-ending"
+	checkWhitespace      = "trailing-whitespace"
+	checkEmptyHeader     = "empty-header"
+	checkDuplicateHeader = "duplicate-header"
+	checkMinimalQuoting  = "minimal-quoting"
+)
+
+var checks = []string{
+	checkBOM, checkEncoding, checkQuote, checkBareQuote, checkParse, checkFieldCount, checkLineEnding,
+	checkWhitespace, checkEmptyHeader, checkDuplicateHeader, checkMinimalQuoting,
+}
+
+// A problem found in a file. Lines and columns are counted like in
+// csv.ParseError.
+type problem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// Lints a file.
+type linter struct {
+	dialect csv.Dialect
+	header  bool
+	// Checks not to report.
+	disabled map[string]bool
+	file     string
+
+	problems []problem
+	// Number of fields of the first record.
+	fieldCount int
+	// Line ending of the first record.
+	lineEnding string
+	// Line endings found that differ from the dialect's.
+	wrongEndings map[string]bool
+}
+
+func (l *linter) report(line, column int, check, format string, args ...interface{}) {
+	if l.disabled[check] {
+		return
+	}
+	l.problems = append(l.problems, problem{l.file, line, column, check, fmt.Sprintf(format, args...)})
+}
+
+// Lints all records of in and returns the problems found.
+func (l *linter) lint(in io.Reader) ([]problem, error) {
+	l.fieldCount = -1
+	l.wrongEndings = make(map[string]bool)
+	br := bufio.NewReader(in)
+	if start, _ := br.Peek(3); bytes.HasPrefix(start, []byte("\xef\xbb\xbf")) {
+		l.report(1, 1, checkBOM, "UTF-8 byte order mark")
+	} else if bytes.HasPrefix(start, []byte("\xff\xfe")) || bytes.HasPrefix(start, []byte("\xfe\xff")) {
+		l.report(1, 1, checkBOM, "UTF-16 byte order mark")
+	}
+
+	d := l.dialect
+	d.OnInvalid = csv.InvalidError
+	r := csv.NewDialectReader(br, d)
+	r.KeepRecordText = true
+	r.ErrorHandler = func(text []byte, err *csv.ParseError) {
+		var encodingErr *csv.EncodingError
+		switch {
+		case errors.Is(err, csv.ErrQuote):
+			l.report(err.Line, err.Column, checkQuote, "%v", err.Err)
+		case errors.Is(err, csv.ErrInvalidUTF8) || errors.As(err, &encodingErr):
+			l.report(err.Line, err.Column, checkEncoding, "%v", err.Err)
+		default:
+			l.report(err.Line, err.Column, checkParse, "%v", err.Err)
+		}
+	}
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			return l.problems, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// Not recoverable, such as an unterminated quote at the end of the
+			// input.
+			l.report(parseErr.Line, parseErr.Column, checkParse, "%v", parseErr.Err)
+			return l.problems, nil
+		}
+		if err != nil {
+			return l.problems, err
+		}
+		l.lintRecord(record, r.RecordText(), r.RecordLine(), first)
+	}
+}
+
+// A field as it appears in the input.
+type rawField struct {
+	line, column int
+	quoted       bool
+	// The unquoted text of the field, if not quoted.
+	text string
+}
+
+func (l *linter) lintRecord(record []string, text []byte, line int, first bool) {
+	fields, strippedCR := l.scan(text, line)
+	if strippedCR && len(record) > 0 {
+		// Reported as a line ending problem, so not part of the value.
+		last := len(record) - 1
+		record[last] = strings.TrimSuffix(record[last], "\r")
+	}
+	if first {
+		l.fieldCount = len(record)
+	} else if len(record) != l.fieldCount {
+		l.report(line, 1, checkFieldCount, "record has %d fields, the first record has %d", len(record), l.fieldCount)
+	}
+	if first && l.header {
+		l.lintHeader(record, fields)
+	}
+	if len(fields) != len(record) {
+		return
+	}
+	for i, f := range fields {
+		value := record[i]
+		if !f.quoted {
+			if n := len(f.text); n > 0 && (f.text[n-1] == ' ' || f.text[n-1] == '\t') {
+				l.report(f.line, f.column, checkWhitespace, "field %q ends with whitespace", value)
+			}
+			continue
+		}
+		if value == "" {
+			l.report(f.line, f.column, checkMinimalQuoting, "quoted empty field can't be told apart from an empty field with minimal quoting")
+		} else if quotedNumber.MatchString(value) {
+			l.report(f.line, f.column, checkMinimalQuoting, "quoted %q is written unquoted with minimal quoting, and might be read as a number", value)
+		}
+	}
+}
+
+// Numbers whose quotes likely keep spreadsheets from dropping a leading zero
+// or plus sign.
+var quotedNumber = regexp.MustCompile(`^(\+[0-9]|[+-]?0[0-9])[0-9]*$`)
+
+func (l *linter) lintHeader(header []string, fields []rawField) {
+	position := func(i int) (int, int) {
+		if len(fields) == len(header) {
+			return fields[i].line, fields[i].column
+		}
+		return fields[0].line, 1
+	}
+	seen := make(map[string]int)
+	for i, name := range header {
+		line, column := position(i)
+		if name == "" {
+			l.report(line, column, checkEmptyHeader, "column %d has an empty header", i+1)
+			continue
+		}
+		if j, ok := seen[name]; ok {
+			l.report(line, column, checkDuplicateHeader, "header %q of column %d is also used by column %d", name, i+1, j+1)
+			continue
+		}
+		seen[name] = i
+	}
+}
+
+// Splits the text of a record into fields, and checks its line endings and
+// unquoted fields for stray quotes. Also returns whether a carriage return
+// before the line terminator was left out of the last field.
+func (l *linter) scan(text []byte, line int) ([]rawField, bool) {
+	d := l.dialect
+	content := bytes.TrimSuffix(text, []byte(d.LineTerminator))
+	ending := ""
+	if len(content) < len(text) {
+		ending = d.LineTerminator
+		if d.LineTerminator == "\n" && bytes.HasSuffix(content, []byte("\r")) {
+			content = content[:len(content)-1]
+			ending = "\r\n"
+		}
+	}
+	if ending != "" {
+		if l.lineEnding == "" {
+			l.lineEnding = ending
+		} else if ending != l.lineEnding {
+			l.report(line, 1, checkLineEnding, "mixed line endings: record ends with %q, the first record with %q", ending, l.lineEnding)
+		}
+		if ending != d.LineTerminator && !l.wrongEndings[ending] {
+			l.wrongEndings[ending] = true
+			l.report(line, 1, checkLineEnding, "record ends with %q, but the dialect's line terminator is %q", ending, d.LineTerminator)
+		}
+	}
+
+	var fields []rawField
+	column := 1
+	i := 0
+	// Reads the next rune, keeping track of the position.
+	next := func() rune {
+		r, size := utf8.DecodeRune(content[i:])
+		i += size
+		column += size
+		if r == '\n' {
+			line++
+			column = 1
+		}
+		return r
+	}
+	peek := func() rune {
+		r, _ := utf8.DecodeRune(content[i:])
+		return r
+	}
+	delimiter := []byte(string(d.Delimiter))
+	lineBreakReported := false
+	for {
+		if d.SkipInitialSpace {
+			for i < len(content) && content[i] == ' ' {
+				next()
+			}
+		}
+		f := rawField{line: line, column: column}
+		if i < len(content) && peek() == d.QuoteChar {
+			f.quoted = true
+			next()
+			for i < len(content) {
+				r := next()
+				if r == d.EscapeChar && d.DoubleQuote == csv.NoDoubleQuote && i < len(content) {
+					next()
+				} else if r == d.QuoteChar {
+					if d.DoubleQuote == csv.DoDoubleQuote && i < len(content) && peek() == d.QuoteChar {
+						next()
+					} else {
+						break
+					}
+				}
+			}
+		}
+		start := i
+		for i < len(content) && !bytes.HasPrefix(content[i:], delimiter) {
+			l0, c0 := line, column
+			switch r := next(); {
+			case r == d.QuoteChar && !f.quoted:
+				l.report(l0, c0, checkBareQuote, "quote character in unquoted field")
+			case (r == '\r' || r == '\n') && !f.quoted && !lineBreakReported:
+				lineBreakReported = true
+				l.report(l0, c0, checkLineEnding, "line break %q in unquoted field, but the dialect's line terminator is %q", string(r), d.LineTerminator)
+			}
+		}
+		f.text = string(content[start:i])
+		fields = append(fields, f)
+		if i >= len(content) {
+			return fields, ending == "\r\n" && d.LineTerminator == "\n"
+		}
+		i += len(delimiter)
+		column += len(delimiter)
+	}
+}
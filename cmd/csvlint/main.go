@@ -0,0 +1,142 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+// Csvlint checks CSV files against a dialect and reports every problem found,
+// with its line and column:
+//
+//	csvlint -fields-terminated-by , -double-quote data/*.csv
+//
+// The dialect is given using the flags of the dialect package. Run csvlint
+// -help for all flags. Lines starting with # are records, and are checked,
+// unless -comment is set. These checks are made:
+//
+//	bom                  byte order marks
+//	invalid-encoding     input that isn't valid in the dialect's encoding
+//	quote                unterminated quotes, and quoted fields followed by
+//	                     something other than a delimiter
+//	bare-quote           quote characters in unquoted fields
+//	parse-error          other errors reading records
+//	field-count          records with another number of fields than the first
+//	line-ending          mixed line endings, and line endings that don't
+//	                     match the dialect
+//	trailing-whitespace  unquoted fields ending with whitespace
+//	empty-header         empty column names in the header
+//	duplicate-header     column names used more than once in the header
+//	minimal-quoting      quoted fields whose quotes carry meaning that is
+//	                     lost when rewritten with minimal quoting: empty
+//	                     fields and numbers with leading zeros or plus signs
+//
+// Checks can be turned off using -disable. Malformed records are reported and
+// skipped, so all problems of a file are found in one run. Problems are
+// printed as text, or as a JSON array using -format json. Files are read from
+// standard input if none are given, or for "-".
+//
+// Exit status is 0 if no problems were found, 1 if there were any and 2 on
+// errors, including invalid flags.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/JensRantil/go-csv/dialect"
+)
+
+// Exit statuses.
+const (
+	exitOK = iota
+	exitProblems
+	exitError
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fset := flag.NewFlagSet("csvlint", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	builder := dialect.FromFlagSet(fset)
+	format := fset.String("format", "text", "output format: text or json")
+	header := fset.Bool("header", true, "whether the first record is a header with column names")
+	disable := fset.String("disable", "", "comma separated checks not to make: "+strings.Join(checks, ", "))
+	fset.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: csvlint [flags] [file ...]")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintln(stderr, "csvlint:", err)
+		return exitError
+	}
+	if *format != "text" && *format != "json" {
+		return fail(fmt.Errorf("unknown format %q.", *format))
+	}
+	disabled := make(map[string]bool)
+	if *disable != "" {
+		for _, check := range strings.Split(*disable, ",") {
+			check = strings.TrimSpace(check)
+			known := false
+			for _, c := range checks {
+				known = known || c == check
+			}
+			if !known {
+				return fail(fmt.Errorf("unknown check %q.", check))
+			}
+			disabled[check] = true
+		}
+	}
+	d, err := builder.Dialect()
+	if err != nil {
+		return fail(err)
+	}
+
+	paths := fset.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	problems := []problem{}
+	for _, path := range paths {
+		l := linter{dialect: *d, header: *header, disabled: disabled, file: path}
+		var found []problem
+		if path == "-" {
+			found, err = l.lint(stdin)
+		} else {
+			var f *os.File
+			if f, err = os.Open(path); err == nil {
+				found, err = l.lint(f)
+				f.Close()
+			}
+		}
+		if err != nil {
+			return fail(err)
+		}
+		problems = append(problems, found...)
+	}
+
+	if *format == "json" {
+		b, err := json.MarshalIndent(problems, "", "  ")
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Fprintf(stdout, "%s\n", b)
+	} else {
+		for _, p := range problems {
+			fmt.Fprintf(stdout, "%s:%d:%d: %s (%s)\n", p.File, p.Line, p.Column, p.Message, p.Check)
+		}
+	}
+	if len(problems) > 0 {
+		return exitProblems
+	}
+	return exitOK
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var commaDialect = []string{"-fields-terminated-by", ",", "-double-quote"}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"id,name\n1,a\n", ""},
+		{"\xef\xbb\xbfid,name\n1,a\n", "-:1:1: UTF-8 byte order mark (bom)\n"},
+		{"id,name\n1,a\"b\n", "-:2:4: quote character in unquoted field (bare-quote)\n"},
+		{"id,name\n\"1\"x,a\n", "-:2:4: extraneous or missing quote in quoted field (quote)\n"},
+		{"id,name\n\"1,a\n", "-:3:1: extraneous or missing quote in quoted field (quote)\n"},
+		{"id,name\n1,\xff\n", "-:2:3: invalid UTF-8 (invalid-encoding)\n"},
+		{"id,name\n1,a,b\n", "-:2:1: record has 3 fields, the first record has 2 (field-count)\n"},
+		{"id,name\n#1,a,b\n", "-:2:1: record has 3 fields, the first record has 2 (field-count)\n"},
+		{"id,name\r\n1,a\n", "-:1:1: record ends with \"\\r\\n\", but the dialect's line terminator is \"\\n\" (line-ending)\n" +
+			"-:2:1: mixed line endings: record ends with \"\\n\", the first record with \"\\r\\n\" (line-ending)\n"},
+		{"id,name\n1 ,a\n", "-:2:1: field \"1 \" ends with whitespace (trailing-whitespace)\n"},
+		{"id,\r\n", "-:1:1: record ends with \"\\r\\n\", but the dialect's line terminator is \"\\n\" (line-ending)\n" +
+			"-:1:4: column 2 has an empty header (empty-header)\n"},
+		{"id,id\n1,a\n", "-:1:4: header \"id\" of column 2 is also used by column 1 (duplicate-header)\n"},
+		{"id,name\n\"\",a\n\"007\",b\n\"7\",c\n", "-:2:1: quoted empty field can't be told apart from an empty field with minimal quoting (minimal-quoting)\n" +
+			"-:3:1: quoted \"007\" is written unquoted with minimal quoting, and might be read as a number (minimal-quoting)\n"},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(commaDialect, strings.NewReader(test.input), &stdout, &stderr)
+		expectedStatus := exitProblems
+		if test.expected == "" {
+			expectedStatus = exitOK
+		}
+		if status != expectedStatus || stdout.String() != test.expected {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q\n%s", test.input, status, stdout.String(), expectedStatus, test.expected, stderr.String())
+		}
+	}
+}
+
+func TestLintFlags(t *testing.T) {
+	t.Parallel()
+
+	input := "id,id\n\"1\",a \n"
+	tests := []struct {
+		args     []string
+		expected string
+		status   int
+	}{
+		{[]string{"-header=false"}, "-:2:5: field \"a \" ends with whitespace (trailing-whitespace)\n", exitProblems},
+		{[]string{"-disable", "duplicate-header, trailing-whitespace"}, "", exitOK},
+		{[]string{"-disable", "no-such-check"}, "", exitError},
+		{[]string{"-format", "xml"}, "", exitError},
+		{[]string{"-format", "json", "-disable", "duplicate-header"}, `[
+  {
+    "file": "-",
+    "line": 2,
+    "column": 5,
+    "check": "trailing-whitespace",
+    "message": "field \"a \" ends with whitespace"
+  }
+]
+`, exitProblems},
+		{[]string{"-format", "json", "-disable", "duplicate-header,trailing-whitespace"}, "[]\n", exitOK},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(append(append([]string{}, commaDialect...), test.args...), strings.NewReader(input), &stdout, &stderr)
+		if status != test.status || stdout.String() != test.expected {
+			t.Errorf("%q: Unexpected output: %d %q Expected: %d %q\n%s", test.args, status, stdout.String(), test.status, test.expected, stderr.String())
+		}
+	}
+}
+
+func TestLintFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.csv")
+	ragged := filepath.Join(dir, "ragged.csv")
+	if err := os.WriteFile(clean, []byte("id,name\n1,a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ragged, []byte("id,name\n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := append(append([]string{}, commaDialect...), clean, ragged, "-")
+	status := run(args, strings.NewReader("id\n\"1\n"), &stdout, &stderr)
+	expected := ragged + ":2:1: record has 1 fields, the first record has 2 (field-count)\n" +
+		"-:3:1: extraneous or missing quote in quoted field (quote)\n"
+	if status != exitProblems || stdout.String() != expected {
+		t.Error("Unexpected output:", status, stdout.String(), "Expected:", expected, stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	args = append(append([]string{}, commaDialect...), clean, filepath.Join(dir, "missing.csv"))
+	if status := run(args, strings.NewReader(""), &stdout, &stderr); status != exitError {
+		t.Error("Unexpected output:", status, "Expected:", exitError)
+	}
+}
@@ -6,7 +6,9 @@
 package csv
 
 import (
-	"unicode"
+	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 // QuoteMode defines how quotes should be handled.
@@ -24,6 +26,33 @@ const (
 	QuoteNone = iota
 )
 
+var quoteModeNames = map[QuoteMode]string{
+	QuoteDefault:            "default",
+	QuoteAll:                "all",
+	QuoteMinimal:            "minimal",
+	QuoteNonNumeric:         "nonnumeric",
+	QuoteNonNumericNonEmpty: "nonnumericnonempty",
+	QuoteNone:               "none",
+}
+
+func (m QuoteMode) String() string {
+	if name, ok := quoteModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("QuoteMode(%d)", int(m))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m QuoteMode) MarshalText() ([]byte, error) {
+	return marshalName(quoteModeNames, m, "quoting mode")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Names are the ones
+// returned by String, and are matched case insensitively.
+func (m *QuoteMode) UnmarshalText(text []byte) error {
+	return unmarshalName(quoteModeNames, m, text, "quoting mode")
+}
+
 // DoubleQuoteMode defined how quote excaping should be done.
 type DoubleQuoteMode int
 
@@ -45,6 +74,9 @@ const (
 	DefaultComment        = '#'
 )
 
+// NoComment is used as Dialect.Comment to turn comments off.
+const NoComment rune = -1
+
 // A Dialect specifies the format of a CSV file. This structure is used by a
 // Reader or Writer to know how to operate on the file they are
 // reading/writing.
@@ -54,10 +86,16 @@ type Dialect struct {
 	Delimiter rune
 	// What quoting mode to use. Defaults to DefaultQuoting.
 	Quoting QuoteMode
+	// How numbers are written. Decides which fields QuoteNonNumeric and
+	// QuoteNonNumericNonEmpty leave unquoted. Defaults to a decimal point and
+	// no grouping, see IsNumeric.
+	NumberFormat NumberFormat
 	// How to escape quotes. Defaults to DefaultDoubleQuote.
 	DoubleQuote DoubleQuoteMode
 	// Character to use for escaping. Only used if DoubleQuote==NoDoubleQuote.
-	// Defaults to DefaultEscapeChar.
+	// Defaults to DefaultEscapeChar. When reading, it escapes the quote
+	// character and itself in quoted fields. Followed by anything else it is
+	// read as is.
 	EscapeChar rune
 	// Character to use as quotation mark around quoted fields. Defaults to
 	// DefaultQuoteChar.
@@ -66,7 +104,8 @@ type Dialect struct {
 	// DefaultLineTerminator.
 	LineTerminator string
 
-	// Comment, if not 0, is the comment character. Lines beginning with the
+	// Comment is the comment character. Defaults to DefaultComment. Set it to
+	// NoComment to read every line as a record. Lines beginning with the
 	// Comment character without preceding whitespace are ignored.
 	// With leading whitespace the Comment character becomes part of the
 	// field, even if TrimLeadingSpace is true.
@@ -74,6 +113,22 @@ type Dialect struct {
 	// or the Unicode replacement character (0xFFFD).
 	// It must also not be equal to Comma.
 	Comment rune
+	// If true, spaces at the start of fields are skipped when reading, so that
+	// "a, b" is read as "a" and "b", and `a, "b"` as a quoted field. Ignored if
+	// Delimiter is a space.
+	SkipInitialSpace bool
+
+	// Character encoding of the file. Defaults to UTF8.
+	Encoding Encoding
+	// How byte sequences that are invalid in Encoding, and characters that
+	// can't be represented in it, are handled. Also applies to invalid UTF-8
+	// read by a Reader when Encoding is UTF8. Defaults to InvalidReplace.
+	OnInvalid InvalidPolicy
+
+	// How fields that spreadsheet applications would interpret as formulas are
+	// neutralised when writing. A Reader reverses it. Defaults to
+	// FormulaEscapeNone.
+	FormulaEscape FormulaEscapeMode
 }
 
 func (wo *Dialect) setDefaults() {
@@ -100,16 +155,115 @@ func (wo *Dialect) setDefaults() {
 	}
 }
 
-func isNumeric(s string) bool {
-	if len(s) == 0 {
+func marshalName[T comparable](names map[T]string, v T, kind string) ([]byte, error) {
+	if name, ok := names[v]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("csv: unknown %s %v", kind, v)
+}
+
+func unmarshalName[T comparable](names map[T]string, v *T, text []byte, kind string) error {
+	for value, name := range names {
+		if strings.EqualFold(name, string(text)) {
+			*v = value
+			return nil
+		}
+	}
+	return fmt.Errorf("csv: unknown %s %q", kind, text)
+}
+
+// NumberFormat describes how numbers are written, for example with a decimal
+// comma and grouping of thousands.
+type NumberFormat struct {
+	// Character separating the integer part from the fractional part. Defaults
+	// to '.'.
+	Decimal rune
+	// Character separating groups of thousands in the integer part, such as
+	// ',' in "1,000,000". If 0, grouping is not accepted.
+	Grouping rune
+}
+
+// IsNumeric reports whether s is a number: an optional sign followed by an
+// integer or decimal number with an optional exponent. Examples are "42",
+// "-5", "+3.14", ".5" and "1e10". Only ASCII digits are accepted, so digits of
+// other scripts, such as the Arabic-Indic "١", are not numeric.
+func IsNumeric(s string) bool {
+	return NumberFormat{}.IsNumeric(s)
+}
+
+// IsNumeric reports whether s is a number written using f. Grouping is
+// optional, but when used every group after the first must have exactly three
+// digits. For example, NumberFormat{Decimal: ',', Grouping: '.'} accepts
+// "1.000,5" and "1000,5" but not "1.00,5".
+func (f NumberFormat) IsNumeric(s string) bool {
+	decimal := f.Decimal
+	if decimal == 0 {
+		decimal = '.'
+	}
+	grouping := f.Grouping
+	if grouping == decimal {
+		grouping = 0
+	}
+
+	s = trimSign(s)
+	intDigits, s, ok := scanGroupedDigits(s, grouping)
+	if !ok {
 		return false
 	}
-	for _, r := range s {
-		if r != '.' && !unicode.IsDigit(r) {
+	fracDigits := 0
+	if r, size := utf8.DecodeRuneInString(s); size > 0 && r == decimal {
+		fracDigits, s = scanDigits(s[size:])
+	}
+	if intDigits == 0 && fracDigits == 0 {
+		return false
+	}
+	if len(s) > 0 && (s[0] == 'e' || s[0] == 'E') {
+		var expDigits int
+		expDigits, s = scanDigits(trimSign(s[1:]))
+		if expDigits == 0 {
 			return false
 		}
 	}
-	return true
+	return len(s) == 0
+}
+
+func trimSign(s string) string {
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		return s[1:]
+	}
+	return s
+}
+
+// scanDigits returns the number of leading ASCII digits in s together with
+// the remainder of s.
+func scanDigits(s string) (int, string) {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n, s[n:]
+}
+
+// scanGroupedDigits is like scanDigits, but also accepts digits grouped in
+// thousands separated by grouping. ok is false if grouping was used
+// incorrectly.
+func scanGroupedDigits(s string, grouping rune) (n int, rest string, ok bool) {
+	n, rest = scanDigits(s)
+	if grouping == 0 || n == 0 || !strings.HasPrefix(rest, string(grouping)) {
+		return n, rest, true
+	}
+	if n > 3 {
+		return n, rest, false
+	}
+	for strings.HasPrefix(rest, string(grouping)) {
+		group, after := scanDigits(rest[utf8.RuneLen(grouping):])
+		if group != 3 {
+			return n, rest, false
+		}
+		n += group
+		rest = after
+	}
+	return n, rest, true
 }
 
 func isEmpty(s string) bool {
@@ -16,21 +16,117 @@ func TestIsNumeric(t *testing.T) {
 		"a",
 		"1a",
 		"a1",
+		".",
+		"...",
+		"1.2.3",
+		"-",
+		"+",
+		"1e",
+		"1e+",
+		"e10",
+		"1,000",
+		"--5",
+		" 1",
+		"1 ",
+		"١",
 	}
 	numeric := []string{
 		"1",
 		"11",
 		"123456789",
 		"1.2",
+		"-5",
+		"+3",
+		"1.",
+		".5",
+		"-.5",
+		"1e10",
+		"1E-10",
+		"+6.02e+23",
 	}
 	for _, item := range numeric {
-		if !isNumeric(item) {
+		if !IsNumeric(item) {
 			t.Error("Should be numeric:", item)
 		}
 	}
 	for _, item := range notNumeric {
-		if isNumeric(item) {
+		if IsNumeric(item) {
 			t.Error("Should not be numeric:", item)
 		}
 	}
 }
+
+func TestNumberFormat(t *testing.T) {
+	t.Parallel()
+
+	f := NumberFormat{Decimal: ',', Grouping: '.'}
+	numeric := []string{
+		"1",
+		"1000",
+		"1.000",
+		"-1.000.000,25",
+		"12.345,",
+		",5",
+		"1,5e3",
+	}
+	notNumeric := []string{
+		"1.2",
+		"1.00",
+		"1000.000",
+		".000",
+		"1..000",
+		"1.000.",
+		"1,000,5",
+		",",
+	}
+	for _, item := range numeric {
+		if !f.IsNumeric(item) {
+			t.Error("Should be numeric:", item)
+		}
+	}
+	for _, item := range notNumeric {
+		if f.IsNumeric(item) {
+			t.Error("Should not be numeric:", item)
+		}
+	}
+
+	if g := (NumberFormat{Grouping: '\u00a0'}); !g.IsNumeric("1\u00a0234.5") {
+		t.Error("Expected multi-byte grouping to be supported.")
+	}
+}
+
+func TestTextNames(t *testing.T) {
+	t.Parallel()
+
+	var quoting QuoteMode
+	if err := quoting.UnmarshalText([]byte("NonNumeric")); err != nil || quoting != QuoteNonNumeric {
+		t.Error("Unexpected output:", quoting, err)
+	}
+	if err := quoting.UnmarshalText([]byte("sometimes")); err == nil {
+		t.Error("Expected an error.")
+	}
+	if text, _ := QuoteMode(QuoteNonNumericNonEmpty).MarshalText(); string(text) != "nonnumericnonempty" {
+		t.Error("Unexpected output:", string(text))
+	}
+	if _, err := QuoteMode(42).MarshalText(); err == nil {
+		t.Error("Expected an error.")
+	}
+
+	var encoding Encoding
+	if err := encoding.UnmarshalText([]byte("CP1252")); err != nil || encoding != Windows1252 {
+		t.Error("Unexpected output:", encoding, err)
+	}
+	if err := encoding.UnmarshalText([]byte("utf-16be")); err != nil || encoding != UTF16BE {
+		t.Error("Unexpected output:", encoding, err)
+	}
+
+	var policy InvalidPolicy
+	if err := policy.UnmarshalText([]byte("passthrough")); err != nil || policy != InvalidPassThrough {
+		t.Error("Unexpected output:", policy, err)
+	}
+
+	var formulaEscape FormulaEscapeMode
+	if err := formulaEscape.UnmarshalText([]byte(FormulaEscapeQuotedPrefix.String())); err != nil || formulaEscape != FormulaEscapeQuotedPrefix {
+		t.Error("Unexpected output:", formulaEscape, err)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+const conformanceDir = "testdata/conformance"
+
+// A test vector parsed by Python's csv module. See
+// testdata/conformance/generate.py.
+type conformanceCase struct {
+	Name    string
+	File    string
+	Dialect struct {
+		Delimiter      string
+		QuoteChar      string
+		DoubleQuote    bool
+		EscapeChar     *string
+		LineTerminator string
+	}
+	Records [][]string
+}
+
+// An intentional difference between this package and Python's csv module.
+type conformanceDeviation struct {
+	Reason  string
+	Records [][]string
+}
+
+func (c conformanceCase) dialect() Dialect {
+	delimiter, _ := utf8.DecodeRuneInString(c.Dialect.Delimiter)
+	quoteChar, _ := utf8.DecodeRuneInString(c.Dialect.QuoteChar)
+	d := Dialect{
+		Delimiter:      delimiter,
+		QuoteChar:      quoteChar,
+		LineTerminator: c.Dialect.LineTerminator,
+		DoubleQuote:    DoDoubleQuote,
+	}
+	if !c.Dialect.DoubleQuote {
+		d.DoubleQuote = NoDoubleQuote
+	}
+	if c.Dialect.EscapeChar != nil {
+		d.EscapeChar, _ = utf8.DecodeRuneInString(*c.Dialect.EscapeChar)
+	}
+	return d
+}
+
+func readJSON(t *testing.T, filename string, v interface{}) {
+	b, err := os.ReadFile(filepath.Join(conformanceDir, filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatal(filename, err)
+	}
+}
+
+// Test reading against the output of Python's csv module.
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	var cases []conformanceCase
+	readJSON(t, "expected.json", &cases)
+	var deviations map[string]conformanceDeviation
+	readJSON(t, "deviations.json", &deviations)
+
+	for _, c := range cases {
+		f, err := os.Open(filepath.Join(conformanceDir, c.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := NewDialectReader(f, c.dialect()).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Error(c.Name, "Unexpected error:", err)
+			continue
+		}
+
+		expected := c.Records
+		deviation, deviates := deviations[c.Name]
+		if deviates {
+			expected = deviation.Records
+			if reflect.DeepEqual(records, c.Records) {
+				t.Error(c.Name, "Deviation no longer applies. Remove it from deviations.json.")
+			}
+		}
+		if !reflect.DeepEqual(records, expected) {
+			t.Errorf("%s: Unexpected records.\nread=%q\nexpected=%q", c.Name, records, expected)
+		}
+	}
+}
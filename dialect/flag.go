@@ -9,16 +9,38 @@ package dialect
 import (
 	"errors"
 	"flag"
-	csv "github.com/JensRantil/go-csv"
+	"fmt"
+	"strconv"
 	"strings"
 	"unicode/utf8"
+
+	csv "github.com/JensRantil/go-csv"
+)
+
+// Names of the flags registered by a DialectBuilder, before prefixing.
+const (
+	DelimiterFlag        = "fields-terminated-by"
+	QuoteCharFlag        = "fields-optionally-enclosed-by"
+	EscapeCharFlag       = "fields-escaped-by"
+	LineTerminatorFlag   = "lines-terminated-by"
+	QuotingFlag          = "quoting"
+	DecimalFlag          = "decimal-separator"
+	GroupingFlag         = "grouping-separator"
+	DoubleQuoteFlag      = "double-quote"
+	CommentFlag          = "comment"
+	SkipInitialSpaceFlag = "skip-initial-space"
+	EncodingFlag         = "encoding"
+	OnInvalidFlag        = "on-invalid"
+	FormulaEscapeFlag    = "formula-escape"
 )
 
 type DialectBuilder struct {
-	quoteCharString     *string
-	escapeCharString    *string
-	delimiterCharString *string
-	flagSet             *flag.FlagSet
+	flagSet *flag.FlagSet
+	prefix  string
+	// Environment variable prefix, if reading from the environment.
+	envPrefix *string
+	// Config files to read, with the last one taking precedence.
+	configFiles []string
 }
 
 // Construct a CSV Dialect from command line using the `flag` package. This is
@@ -26,71 +48,206 @@ type DialectBuilder struct {
 // register other flags. Call `flag.Parse()`. A dialect can then be constructed
 // by calling `DialectBuilder.Dialect()`.
 func FromCommandLine() *DialectBuilder {
-	// flag package did not expose the CommandLine variable before Go 1.2. This
-	// is a workaround.
-	p := DialectBuilder{}
-	p.delimiterCharString = flag.String("fields-terminated-by", "\t", "character to terminate fields by")
-	p.quoteCharString = flag.String("fields-optionally-enclosed-by", "\"", "character to enclose fields with when needed")
-	p.escapeCharString = flag.String("fields-escaped-by", "\\", "character to escape special characters with")
-	p.flagSet = nil
-	return &p
+	return FromFlagSet(flag.CommandLine)
 }
 
 // Constructs a CSV Dialect from a specific flagset. Essentially the same as
 // `FromCommandLine()`, except it supports a custom FlagSet. See
 // `FromCommandLine()` for a description on how to use this one.
 func FromFlagSet(f *flag.FlagSet) *DialectBuilder {
-	p := DialectBuilder{}
-	p.delimiterCharString = f.String("fields-terminated-by", "\t", "character to terminate fields by")
-	p.quoteCharString = f.String("fields-optionally-enclosed-by", "\"", "character to enclose fields with when needed")
-	p.escapeCharString = f.String("fields-escaped-by", "\\", "character to escape special characters with")
-	p.flagSet = f
+	return FromFlagSetWithPrefix(f, "")
+}
+
+// Same as `FromFlagSet()`, but every flag name is prefixed with prefix. This
+// makes it possible to register several dialects in the same FlagSet, for
+// example using the prefixes "input-" and "output-".
+func FromFlagSetWithPrefix(f *flag.FlagSet, prefix string) *DialectBuilder {
+	p := DialectBuilder{flagSet: f, prefix: prefix}
+	f.String(prefix+DelimiterFlag, "\t", "character to terminate fields by")
+	f.String(prefix+QuoteCharFlag, "\"", "character to enclose fields with when needed")
+	f.String(prefix+EscapeCharFlag, "\\", "character to escape special characters with")
+	f.String(prefix+LineTerminatorFlag, csv.DefaultLineTerminator, "string to terminate lines by")
+	f.String(prefix+QuotingFlag, csv.QuoteMode(csv.QuoteMinimal).String(), "when to quote fields: all, minimal, nonnumeric, nonnumericnonempty or none")
+	f.String(prefix+DecimalFlag, ".", "character separating the integer and fractional parts of numbers")
+	f.String(prefix+GroupingFlag, "", "character separating groups of thousands in numbers; empty for none")
+	f.Bool(prefix+DoubleQuoteFlag, false, "escape quote characters by doubling them instead of using the escape character")
+	f.String(prefix+CommentFlag, "", "lines starting with this character are ignored when reading; empty for none")
+	f.Bool(prefix+SkipInitialSpaceFlag, false, "skip spaces at the start of fields when reading")
+	f.String(prefix+EncodingFlag, csv.UTF8.String(), "character encoding: utf-8, utf-16le, utf-16be, iso-8859-1 or windows-1252")
+	f.String(prefix+OnInvalidFlag, csv.InvalidReplace.String(), "how to handle invalid characters: replace, error or passthrough")
+	f.String(prefix+FormulaEscapeFlag, csv.FormulaEscapeNone.String(), "how to neutralise spreadsheet formulas: none, prefix or quoted-prefix")
 	return &p
 }
 
 // Construct a Dialect from a FlagSet. Make sure to parse the FlagSet before
 // calling this.
+//
+// Character and string flags accept Go escape sequences such as `\t` and
+// `\x1f`. A single character is always taken literally.
 func (p *DialectBuilder) Dialect() (*csv.Dialect, error) {
-	if p.flagSet != nil {
-		// flag package did not expose the CommandLine variable before Go 1.2. This
-		// is a workaround.
-		if !p.flagSet.Parsed() {
-			// Sure, could call flagSet.Parse() here. However, we don't know if the
-			// user would like to parse something else than argv. Therefor, letting the
-			// user decide.
-			return nil, errors.New("FlagSet has not been parsed before calling this function.")
-		}
-	} else if !flag.Parsed() {
-		// Sure, could call flag.Parse() here. However, we don't know if the
+	if !p.flagSet.Parsed() {
+		// Sure, could call flagSet.Parse() here. However, we don't know if the
 		// user would like to parse something else than argv. Therefor, letting the
 		// user decide.
 		return nil, errors.New("FlagSet has not been parsed before calling this function.")
 	}
 
+	settings, err := p.settings()
+	if err != nil {
+		return nil, err
+	}
+
 	// `FlagSet`s don't have a rune type. Using string instead, but that adds
 	// some manual error checking.
-	if utf8.RuneCountInString(*p.quoteCharString) > 1 {
-		return nil, errors.New("-fields-optionally-enclosed-by can't be more than one character.")
+	delimiterChar, err := settings[DelimiterFlag].char()
+	if err != nil {
+		return nil, err
+	}
+	quoteChar, err := settings[QuoteCharFlag].char()
+	if err != nil {
+		return nil, err
 	}
-	if utf8.RuneCountInString(*p.escapeCharString) > 1 {
-		return nil, errors.New("-fields-escaped-by can't be more than one character.")
+	escapeChar, err := settings[EscapeCharFlag].char()
+	if err != nil {
+		return nil, err
 	}
-	if utf8.RuneCountInString(*p.quoteCharString) < 1 {
-		return nil, errors.New("-fields-optionally-enclosed-by can't be an empty string.")
+	commentChar := csv.NoComment
+	if settings[CommentFlag].value != "" {
+		if commentChar, err = settings[CommentFlag].char(); err != nil {
+			return nil, err
+		}
 	}
-	if utf8.RuneCountInString(*p.escapeCharString) < 1 {
-		return nil, errors.New("-fields-escaped-by can't be an empty string.")
+	lineTerminator, err := settings[LineTerminatorFlag].unescape()
+	if err != nil {
+		return nil, err
+	}
+	if lineTerminator == "" {
+		return nil, fmt.Errorf("%s can't be an empty string.", settings[LineTerminatorFlag].source)
+	}
+	if delimiterChar == quoteChar {
+		return nil, fmt.Errorf("%s and %s can't be the same character.", settings[DelimiterFlag].source, settings[QuoteCharFlag].source)
+	}
+	if escapeChar == quoteChar {
+		return nil, fmt.Errorf("%s and %s can't be the same character.", settings[EscapeCharFlag].source, settings[QuoteCharFlag].source)
+	}
+	if delimiterChar == escapeChar {
+		return nil, fmt.Errorf("%s and %s can't be the same character.", settings[DelimiterFlag].source, settings[EscapeCharFlag].source)
+	}
+	if commentChar == delimiterChar {
+		return nil, fmt.Errorf("%s and %s can't be the same character.", settings[CommentFlag].source, settings[DelimiterFlag].source)
+	}
+	if commentChar == quoteChar {
+		return nil, fmt.Errorf("%s and %s can't be the same character.", settings[CommentFlag].source, settings[QuoteCharFlag].source)
+	}
+	if commentChar == escapeChar {
+		return nil, fmt.Errorf("%s and %s can't be the same character.", settings[CommentFlag].source, settings[EscapeCharFlag].source)
+	}
+	if strings.ContainsRune(lineTerminator, delimiterChar) {
+		return nil, fmt.Errorf("%s can't be part of %s.", settings[DelimiterFlag].source, settings[LineTerminatorFlag].source)
+	}
+	if strings.ContainsRune(lineTerminator, quoteChar) {
+		return nil, fmt.Errorf("%s can't be part of %s.", settings[QuoteCharFlag].source, settings[LineTerminatorFlag].source)
+	}
+	if strings.ContainsRune(lineTerminator, commentChar) {
+		return nil, fmt.Errorf("%s can't be part of %s.", settings[CommentFlag].source, settings[LineTerminatorFlag].source)
+	}
+	var numberFormat csv.NumberFormat
+	if numberFormat.Decimal, err = settings[DecimalFlag].char(); err != nil {
+		return nil, err
+	}
+	if settings[GroupingFlag].value != "" {
+		if numberFormat.Grouping, err = settings[GroupingFlag].char(); err != nil {
+			return nil, err
+		}
+	}
+	if numberFormat.Decimal == numberFormat.Grouping {
+		return nil, fmt.Errorf("%s and %s can't be the same character.", settings[DecimalFlag].source, settings[GroupingFlag].source)
+	}
+	doubleQuote, err := settings[DoubleQuoteFlag].bool()
+	if err != nil {
+		return nil, err
+	}
+	skipInitialSpace, err := settings[SkipInitialSpaceFlag].bool()
+	if err != nil {
+		return nil, err
 	}
 
-	quoteChar, _, _ := strings.NewReader(*p.quoteCharString).ReadRune()
-	escapeChar, _, _ := strings.NewReader(*p.escapeCharString).ReadRune()
-	delimiterChar, _, _ := strings.NewReader(*p.delimiterCharString).ReadRune()
 	dialect := csv.Dialect{
-		Delimiter:   delimiterChar,
-		QuoteChar:   quoteChar,
-		EscapeChar:  escapeChar,
-		DoubleQuote: csv.NoDoubleQuote,
+		Delimiter:        delimiterChar,
+		QuoteChar:        quoteChar,
+		EscapeChar:       escapeChar,
+		DoubleQuote:      csv.NoDoubleQuote,
+		LineTerminator:   lineTerminator,
+		Comment:          commentChar,
+		SkipInitialSpace: skipInitialSpace,
+		NumberFormat:     numberFormat,
+	}
+	if doubleQuote {
+		dialect.DoubleQuote = csv.DoDoubleQuote
+	}
+	if err := settings[QuotingFlag].unmarshal(&dialect.Quoting); err != nil {
+		return nil, err
+	}
+	if err := settings[EncodingFlag].unmarshal(&dialect.Encoding); err != nil {
+		return nil, err
+	}
+	if err := settings[OnInvalidFlag].unmarshal(&dialect.OnInvalid); err != nil {
+		return nil, err
+	}
+	if err := settings[FormulaEscapeFlag].unmarshal(&dialect.FormulaEscape); err != nil {
+		return nil, err
 	}
 
 	return &dialect, nil
 }
+
+// char parses a setting that must be a single character.
+func (s setting) char() (rune, error) {
+	value, err := s.unescape()
+	if err != nil {
+		return 0, err
+	}
+	switch utf8.RuneCountInString(value) {
+	case 0:
+		return 0, fmt.Errorf("%s can't be an empty string.", s.source)
+	case 1:
+		char, _ := utf8.DecodeRuneInString(value)
+		return char, nil
+	default:
+		return 0, fmt.Errorf("%s can't be more than one character.", s.source)
+	}
+}
+
+// bool parses a boolean setting.
+func (s setting) bool() (bool, error) {
+	b, err := strconv.ParseBool(s.value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false.", s.source)
+	}
+	return b, nil
+}
+
+// unescape decodes Go escape sequences in a setting.
+func (s setting) unescape() (string, error) {
+	if utf8.RuneCountInString(s.value) == 1 {
+		return s.value, nil
+	}
+	var b strings.Builder
+	for rest := s.value; len(rest) > 0; {
+		char, _, tail, err := strconv.UnquoteChar(rest, 0)
+		if err != nil {
+			return "", fmt.Errorf("%s has an invalid escape sequence: %q", s.source, s.value)
+		}
+		b.WriteRune(char)
+		rest = tail
+	}
+	return b.String(), nil
+}
+
+// unmarshal parses a setting that is the name of a value.
+func (s setting) unmarshal(v interface{ UnmarshalText([]byte) error }) error {
+	if err := v.UnmarshalText([]byte(s.value)); err != nil {
+		return fmt.Errorf("%s: %v", s.source, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package dialect_test
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/dialect"
+)
+
+func TestFlagSetDefaults(t *testing.T) {
+	t.Parallel()
+
+	fset := flag.NewFlagSet("test", flag.ContinueOnError)
+	builder := dialect.FromFlagSet(fset)
+	if _, err := builder.Dialect(); err == nil {
+		t.Error("Expected an error for an unparsed FlagSet.")
+	}
+	fset.Parse(nil)
+
+	d, err := builder.Dialect()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := &csv.Dialect{
+		Delimiter:      '\t',
+		Quoting:        csv.QuoteMinimal,
+		DoubleQuote:    csv.NoDoubleQuote,
+		EscapeChar:     '\\',
+		QuoteChar:      '"',
+		LineTerminator: "\n",
+		Comment:        csv.NoComment,
+		NumberFormat:   csv.NumberFormat{Decimal: '.'},
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("Unexpected output: %+v Expected: %+v", d, expected)
+	}
+}
+
+func TestFlagSetWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	fset := flag.NewFlagSet("test", flag.ContinueOnError)
+	input := dialect.FromFlagSetWithPrefix(fset, "input-")
+	output := dialect.FromFlagSetWithPrefix(fset, "output-")
+	err := fset.Parse([]string{
+		"-input-fields-terminated-by", `\x1f`,
+		"-input-lines-terminated-by", `\r\n`,
+		"-input-double-quote",
+		"-input-encoding", "latin1",
+		"-input-on-invalid", "error",
+		"-input-comment", "#",
+		"-output-fields-terminated-by", ";",
+		"-output-fields-optionally-enclosed-by", "'",
+		"-output-quoting", "nonnumeric",
+		"-output-decimal-separator", ",",
+		"-output-grouping-separator", ".",
+		"-output-comment", `\t`,
+		"-output-formula-escape", "quoted-prefix",
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	d, err := input.Dialect()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := &csv.Dialect{
+		Delimiter:      '\x1f',
+		Quoting:        csv.QuoteMinimal,
+		DoubleQuote:    csv.DoDoubleQuote,
+		EscapeChar:     '\\',
+		QuoteChar:      '"',
+		LineTerminator: "\r\n",
+		Comment:        '#',
+		NumberFormat:   csv.NumberFormat{Decimal: '.'},
+		Encoding:       csv.Latin1,
+		OnInvalid:      csv.InvalidError,
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("Unexpected output: %+v Expected: %+v", d, expected)
+	}
+
+	d, err = output.Dialect()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected = &csv.Dialect{
+		Delimiter:      ';',
+		Quoting:        csv.QuoteNonNumeric,
+		DoubleQuote:    csv.NoDoubleQuote,
+		EscapeChar:     '\\',
+		QuoteChar:      '\'',
+		LineTerminator: "\n",
+		Comment:        '\t',
+		NumberFormat:   csv.NumberFormat{Decimal: ',', Grouping: '.'},
+		FormulaEscape:  csv.FormulaEscapeQuotedPrefix,
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("Unexpected output: %+v Expected: %+v", d, expected)
+	}
+}
+
+func TestFlagSetValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-x-fields-terminated-by", ""}, "-x-fields-terminated-by can't be an empty string."},
+		{[]string{"-x-fields-terminated-by", "ab"}, "-x-fields-terminated-by can't be more than one character."},
+		{[]string{"-x-fields-escaped-by", `\q`}, `-x-fields-escaped-by has an invalid escape sequence: "\\q"`},
+		{[]string{"-x-fields-terminated-by", `"`}, "-x-fields-terminated-by and -x-fields-optionally-enclosed-by can't be the same character."},
+		{[]string{"-x-fields-terminated-by", `\n`}, "-x-fields-terminated-by can't be part of -x-lines-terminated-by."},
+		{[]string{"-x-fields-escaped-by", `"`}, "-x-fields-escaped-by and -x-fields-optionally-enclosed-by can't be the same character."},
+		{[]string{"-x-comment", `\t`}, "-x-comment and -x-fields-terminated-by can't be the same character."},
+		{[]string{"-x-fields-terminated-by", `\\`}, "-x-fields-terminated-by and -x-fields-escaped-by can't be the same character."},
+		{[]string{"-x-comment", `"`}, "-x-comment and -x-fields-optionally-enclosed-by can't be the same character."},
+		{[]string{"-x-comment", `\\`}, "-x-comment and -x-fields-escaped-by can't be the same character."},
+		{[]string{"-x-lines-terminated-by", `"\n`}, "-x-fields-optionally-enclosed-by can't be part of -x-lines-terminated-by."},
+		{[]string{"-x-comment", "#", "-x-lines-terminated-by", `#\n`}, "-x-comment can't be part of -x-lines-terminated-by."},
+		{[]string{"-x-grouping-separator", "."}, "-x-decimal-separator and -x-grouping-separator can't be the same character."},
+		{[]string{"-x-decimal-separator", ""}, "-x-decimal-separator can't be an empty string."},
+		{[]string{"-x-lines-terminated-by", ""}, "-x-lines-terminated-by can't be an empty string."},
+		{[]string{"-x-quoting", "sometimes"}, `-x-quoting: csv: unknown quoting mode "sometimes"`},
+		{[]string{"-x-encoding", "ebcdic"}, `-x-encoding: csv: unknown encoding "ebcdic"`},
+	}
+	for _, test := range tests {
+		fset := flag.NewFlagSet("test", flag.ContinueOnError)
+		builder := dialect.FromFlagSetWithPrefix(fset, "x-")
+		if err := fset.Parse(test.args); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		_, err := builder.Dialect()
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%q: Unexpected error: %v Expected: %s", test.args, err, test.expected)
+		}
+	}
+}
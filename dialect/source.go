@@ -0,0 +1,127 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package dialect
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Names of all settings, which are also the flag names before prefixing.
+var settingNames = []string{
+	DelimiterFlag,
+	QuoteCharFlag,
+	EscapeCharFlag,
+	LineTerminatorFlag,
+	QuotingFlag,
+	DecimalFlag,
+	GroupingFlag,
+	DoubleQuoteFlag,
+	CommentFlag,
+	SkipInitialSpaceFlag,
+	EncodingFlag,
+	OnInvalidFlag,
+	FormulaEscapeFlag,
+}
+
+// The value of a setting, and where it came from for error messages.
+type setting struct {
+	value  string
+	source string
+}
+
+// WithEnv makes `Dialect()` read settings from environment variables. The
+// name of a variable is prefix followed by the flag name in upper case, with
+// dashes replaced by underscores. For example, with the prefix "CSV_" and the
+// flag prefix "input-", the delimiter is read from
+// CSV_INPUT_FIELDS_TERMINATED_BY. Environment variables take precedence over
+// config files, but flags given on the command line take precedence over
+// both.
+func (p *DialectBuilder) WithEnv(prefix string) *DialectBuilder {
+	p.envPrefix = &prefix
+	return p
+}
+
+// WithConfigFile makes `Dialect()` read settings from a config file. Each line
+// of the file is a flag name, including any flag prefix, an equal sign and a
+// value, such as "fields-terminated-by = ;". Surrounding whitespace is
+// ignored, so use an escape sequence such as `\x20` for a space. Empty lines
+// and lines starting with '#' are ignored, and so are names that don't start
+// with the flag prefix. If called several times, later files take precedence.
+// Config files have the lowest precedence, apart from the flag defaults.
+func (p *DialectBuilder) WithConfigFile(path string) *DialectBuilder {
+	p.configFiles = append(p.configFiles, path)
+	return p
+}
+
+// EnvName returns the environment variable a setting is read from, given the
+// flag name before prefixing.
+func (p *DialectBuilder) EnvName(name string) string {
+	if p.envPrefix == nil {
+		return ""
+	}
+	return *p.envPrefix + strings.ToUpper(strings.ReplaceAll(p.prefix+name, "-", "_"))
+}
+
+// settings resolves every setting in order of precedence: flags given on the
+// command line, environment variables, config files and flag defaults.
+func (p *DialectBuilder) settings() (map[string]setting, error) {
+	settings := make(map[string]setting, len(settingNames))
+	for _, name := range settingNames {
+		settings[name] = setting{p.flagSet.Lookup(p.prefix + name).DefValue, "-" + p.prefix + name}
+	}
+	for _, path := range p.configFiles {
+		if err := p.readConfigFile(path, settings); err != nil {
+			return nil, err
+		}
+	}
+	if p.envPrefix != nil {
+		for _, name := range settingNames {
+			if value, ok := os.LookupEnv(p.EnvName(name)); ok {
+				settings[name] = setting{value, "environment variable " + p.EnvName(name)}
+			}
+		}
+	}
+	p.flagSet.Visit(func(f *flag.Flag) {
+		if name := strings.TrimPrefix(f.Name, p.prefix); len(name) < len(f.Name) || p.prefix == "" {
+			if _, ok := settings[name]; ok {
+				settings[name] = setting{f.Value.String(), "-" + f.Name}
+			}
+		}
+	})
+	return settings, nil
+}
+
+func (p *DialectBuilder) readConfigFile(path string, settings map[string]setting) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected name=value.", path, line)
+		}
+		key = strings.TrimSpace(key)
+		name := strings.TrimPrefix(key, p.prefix)
+		if len(name) == len(key) && p.prefix != "" {
+			continue
+		}
+		if _, ok := settings[name]; !ok {
+			return fmt.Errorf("%s:%d: unknown setting %q.", path, line, key)
+		}
+		settings[name] = setting{strings.TrimSpace(value), fmt.Sprintf("%s:%d: %s", path, line, key)}
+	}
+	return scanner.Err()
+}
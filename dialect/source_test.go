@@ -0,0 +1,97 @@
+package dialect_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/dialect"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "dialect.conf")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSourcePrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+# Settings for the input dialect.
+input-fields-terminated-by = ;
+input-quoting = all
+input-lines-terminated-by = \r\n
+input-double-quote = true
+output-quoting = none
+`)
+	t.Setenv("CSV_INPUT_QUOTING", "nonnumeric")
+	t.Setenv("CSV_INPUT_FIELDS_ESCAPED_BY", "/")
+
+	fset := flag.NewFlagSet("test", flag.ContinueOnError)
+	builder := dialect.FromFlagSetWithPrefix(fset, "input-").WithConfigFile(path).WithEnv("CSV_")
+	if err := fset.Parse([]string{"-input-fields-escaped-by", "!"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	d, err := builder.Dialect()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if d.Delimiter != ';' || d.LineTerminator != "\r\n" || d.DoubleQuote != csv.DoDoubleQuote {
+		t.Error("Config file not used:", d)
+	}
+	if d.Quoting != csv.QuoteNonNumeric {
+		t.Error("Environment variable does not take precedence over config file:", d.Quoting)
+	}
+	if d.EscapeChar != '!' {
+		t.Error("Flag does not take precedence over environment variable:", string(d.EscapeChar))
+	}
+	if d.QuoteChar != '"' || d.Encoding != csv.UTF8 {
+		t.Error("Defaults not used:", d)
+	}
+	if name := builder.EnvName(dialect.DelimiterFlag); name != "CSV_INPUT_FIELDS_TERMINATED_BY" {
+		t.Error("Unexpected environment variable:", name)
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	t.Setenv("CSV_ENCODING", "ebcdic")
+	t.Setenv("CSV_FIELDS_TERMINATED_BY", "ab")
+
+	fset := flag.NewFlagSet("test", flag.ContinueOnError)
+	builder := dialect.FromFlagSet(fset).WithEnv("CSV_")
+	fset.Parse(nil)
+	_, err := builder.Dialect()
+	if expected := "environment variable CSV_FIELDS_TERMINATED_BY can't be more than one character."; err == nil || err.Error() != expected {
+		t.Error("Unexpected error:", err, "Expected:", expected)
+	}
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"\nquoting = sometimes\n", `dialect.conf:2: quoting: csv: unknown quoting mode "sometimes"`},
+		{"double-quote = maybe\n", "dialect.conf:1: double-quote must be true or false."},
+		{"delimiter = ,\n", `dialect.conf:1: unknown setting "delimiter".`},
+		{"fields-terminated-by\n", "dialect.conf:1: expected name=value."},
+	}
+	for _, test := range tests {
+		fset := flag.NewFlagSet("test", flag.ContinueOnError)
+		builder := dialect.FromFlagSet(fset).WithConfigFile(writeConfigFile(t, test.content))
+		fset.Parse(nil)
+		_, err := builder.Dialect()
+		if err == nil || !strings.HasSuffix(err.Error(), test.expected) {
+			t.Errorf("%q: Unexpected error: %v Expected: %s", test.content, err, test.expected)
+		}
+	}
+
+	fset = flag.NewFlagSet("test", flag.ContinueOnError)
+	builder = dialect.FromFlagSet(fset).WithConfigFile(filepath.Join(t.TempDir(), "missing.conf"))
+	fset.Parse(nil)
+	if _, err := builder.Dialect(); !os.IsNotExist(err) {
+		t.Error("Unexpected error:", err)
+	}
+}
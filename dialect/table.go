@@ -0,0 +1,167 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package dialect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	csv "github.com/JensRantil/go-csv"
+)
+
+// TableDialect is the JSON description of a CSV dialect used by W3C CSV on the
+// Web (CSVW) metadata and by Frictionless Data "CSV Dialect" descriptors. See
+// https://www.w3.org/TR/tabular-metadata/#dialect-descriptions and
+// https://specs.frictionlessdata.io/csv-dialect/. Unset fields have the
+// defaults of those specifications, except for two. Without a commentPrefix no
+// lines are comments. Without a lineTerminator it is "\n" rather than "\r\n",
+// since a Reader only accepts one line terminator and "\r\n" would read a
+// file with "\n" line endings as a single record. Files with "\r\n" line
+// endings need it set.
+type TableDialect struct {
+	Delimiter        string `json:"delimiter,omitempty"`
+	DoubleQuote      *bool  `json:"doubleQuote,omitempty"`
+	EscapeChar       string `json:"escapeChar,omitempty"`
+	QuoteChar        string `json:"quoteChar,omitempty"`
+	LineTerminator   string `json:"lineTerminator,omitempty"`
+	SkipInitialSpace bool   `json:"skipInitialSpace,omitempty"`
+	// Whether the first record is a header. Not part of csv.Dialect, so it is
+	// up to the caller to handle it. See HasHeader.
+	Header        *bool  `json:"header,omitempty"`
+	CommentPrefix string `json:"commentPrefix,omitempty"`
+	// Fields equal to NullSequence are null. Not part of csv.Dialect, so it is
+	// up to the caller to handle it.
+	NullSequence string `json:"nullSequence,omitempty"`
+	Encoding     string `json:"encoding,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Besides the fields of
+// TableDialect, it accepts Frictionless Data's "commentChar" and CSVW's
+// "lineTerminators", of which the first one is used.
+func (t *TableDialect) UnmarshalJSON(data []byte) error {
+	type plain TableDialect
+	var aux struct {
+		plain
+		CommentChar     string          `json:"commentChar"`
+		LineTerminators json.RawMessage `json:"lineTerminators"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*t = TableDialect(aux.plain)
+	if t.CommentPrefix == "" {
+		t.CommentPrefix = aux.CommentChar
+	}
+	if t.LineTerminator == "" && aux.LineTerminators != nil {
+		var lineTerminators []string
+		if err := json.Unmarshal(aux.LineTerminators, &lineTerminators); err != nil {
+			var lineTerminator string
+			if err := json.Unmarshal(aux.LineTerminators, &lineTerminator); err != nil {
+				return errors.New("lineTerminators must be a string or an array of strings.")
+			}
+			lineTerminators = []string{lineTerminator}
+		}
+		if len(lineTerminators) > 0 {
+			t.LineTerminator = lineTerminators[0]
+		}
+	}
+	return nil
+}
+
+// HasHeader returns whether the first record is a header. Defaults to true.
+func (t TableDialect) HasHeader() bool {
+	return t.Header == nil || *t.Header
+}
+
+// Dialect converts t to a csv.Dialect.
+func (t TableDialect) Dialect() (csv.Dialect, error) {
+	d := csv.Dialect{
+		Delimiter:        ',',
+		QuoteChar:        '"',
+		DoubleQuote:      csv.DoDoubleQuote,
+		LineTerminator:   "\n",
+		Comment:          csv.NoComment,
+		SkipInitialSpace: t.SkipInitialSpace,
+	}
+	var err error
+	if t.Delimiter != "" {
+		if d.Delimiter, err = tableChar("delimiter", t.Delimiter); err != nil {
+			return csv.Dialect{}, err
+		}
+	}
+	if t.QuoteChar != "" {
+		if d.QuoteChar, err = tableChar("quoteChar", t.QuoteChar); err != nil {
+			return csv.Dialect{}, err
+		}
+	}
+	if t.EscapeChar != "" {
+		if t.DoubleQuote != nil && *t.DoubleQuote {
+			return csv.Dialect{}, errors.New("escapeChar can't be used together with doubleQuote.")
+		}
+		if d.EscapeChar, err = tableChar("escapeChar", t.EscapeChar); err != nil {
+			return csv.Dialect{}, err
+		}
+		d.DoubleQuote = csv.NoDoubleQuote
+	} else if t.DoubleQuote != nil && !*t.DoubleQuote {
+		// CSVW escapes quotes using a backslash instead.
+		d.DoubleQuote = csv.NoDoubleQuote
+		d.EscapeChar = '\\'
+	}
+	if t.LineTerminator != "" {
+		d.LineTerminator = t.LineTerminator
+	}
+	if t.CommentPrefix != "" {
+		if d.Comment, err = tableChar("commentPrefix", t.CommentPrefix); err != nil {
+			return csv.Dialect{}, err
+		}
+	}
+	if t.Encoding != "" {
+		if err := d.Encoding.UnmarshalText([]byte(t.Encoding)); err != nil {
+			return csv.Dialect{}, fmt.Errorf("encoding: %v", err)
+		}
+	}
+	return d, nil
+}
+
+// FromDialect converts a csv.Dialect to a TableDialect. Header and
+// NullSequence are left unset. Settings that can't be described, such as
+// quoting modes, are left out.
+func FromDialect(d csv.Dialect) TableDialect {
+	t := TableDialect{
+		Delimiter:        string(orDefault(d.Delimiter, csv.DefaultDelimiter)),
+		QuoteChar:        string(orDefault(d.QuoteChar, csv.DefaultQuoteChar)),
+		LineTerminator:   d.LineTerminator,
+		SkipInitialSpace: d.SkipInitialSpace,
+		Encoding:         d.Encoding.String(),
+	}
+	if d.Comment != csv.NoComment {
+		t.CommentPrefix = string(orDefault(d.Comment, csv.DefaultComment))
+	}
+	if t.LineTerminator == "" {
+		t.LineTerminator = csv.DefaultLineTerminator
+	}
+	doubleQuote := d.DoubleQuote != csv.NoDoubleQuote
+	t.DoubleQuote = &doubleQuote
+	if !doubleQuote {
+		t.EscapeChar = string(orDefault(d.EscapeChar, csv.DefaultEscapeChar))
+	}
+	return t
+}
+
+func orDefault(r, def rune) rune {
+	if r == 0 {
+		return def
+	}
+	return r
+}
+
+func tableChar(name, value string) (rune, error) {
+	if utf8.RuneCountInString(value) != 1 {
+		return 0, fmt.Errorf("%s must be a single character.", name)
+	}
+	r, _ := utf8.DecodeRuneInString(value)
+	return r, nil
+}
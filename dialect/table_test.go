@@ -0,0 +1,132 @@
+package dialect_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/dialect"
+)
+
+func TestTableDialectImport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		descriptor string
+		expected   csv.Dialect
+	}{
+		{
+			`{}`,
+			csv.Dialect{Delimiter: ',', QuoteChar: '"', DoubleQuote: csv.DoDoubleQuote, LineTerminator: "\n", Comment: csv.NoComment},
+		},
+		{
+			// Frictionless Data.
+			`{"delimiter": ";", "escapeChar": "\\", "lineTerminator": "\n", "skipInitialSpace": true, "commentChar": "%", "header": false, "csvddfVersion": 1.2}`,
+			csv.Dialect{Delimiter: ';', QuoteChar: '"', DoubleQuote: csv.NoDoubleQuote, EscapeChar: '\\', LineTerminator: "\n", Comment: '%', SkipInitialSpace: true},
+		},
+		{
+			// CSVW.
+			`{"delimiter": "\t", "doubleQuote": false, "quoteChar": "'", "lineTerminators": ["\n", "\r\n"], "commentPrefix": "#", "encoding": "ISO-8859-1"}`,
+			csv.Dialect{Delimiter: '\t', QuoteChar: '\'', DoubleQuote: csv.NoDoubleQuote, EscapeChar: '\\', LineTerminator: "\n", Comment: '#', Encoding: csv.Latin1},
+		},
+		{
+			`{"lineTerminators": "\r\n"}`,
+			csv.Dialect{Delimiter: ',', QuoteChar: '"', DoubleQuote: csv.DoDoubleQuote, LineTerminator: "\r\n", Comment: csv.NoComment},
+		},
+	}
+	for _, test := range tests {
+		var table dialect.TableDialect
+		if err := json.Unmarshal([]byte(test.descriptor), &table); err != nil {
+			t.Error(test.descriptor, "Unexpected error:", err)
+			continue
+		}
+		d, err := table.Dialect()
+		if err != nil {
+			t.Error(test.descriptor, "Unexpected error:", err)
+			continue
+		}
+		if !reflect.DeepEqual(d, test.expected) {
+			t.Errorf("%s: Unexpected output: %#v Expected: %#v", test.descriptor, d, test.expected)
+		}
+	}
+
+	invalid := []string{
+		`{"delimiter": ",,"}`,
+		`{"escapeChar": "\\", "doubleQuote": true}`,
+		`{"commentPrefix": "//"}`,
+		`{"encoding": "ebcdic"}`,
+	}
+	for _, descriptor := range invalid {
+		var table dialect.TableDialect
+		if err := json.Unmarshal([]byte(descriptor), &table); err != nil {
+			t.Error(descriptor, "Unexpected error:", err)
+			continue
+		}
+		if _, err := table.Dialect(); err == nil {
+			t.Error(descriptor, "Expected an error.")
+		}
+	}
+	var table dialect.TableDialect
+	if err := json.Unmarshal([]byte(`{"lineTerminators": 1}`), &table); err == nil {
+		t.Error("Expected an error.")
+	}
+}
+
+func TestTableDialectHeader(t *testing.T) {
+	t.Parallel()
+
+	var table dialect.TableDialect
+	if !table.HasHeader() {
+		t.Error("Expected a header by default.")
+	}
+	json.Unmarshal([]byte(`{"header": false, "nullSequence": "NA"}`), &table)
+	if table.HasHeader() || table.NullSequence != "NA" {
+		t.Error("Unexpected output:", table)
+	}
+
+	d, _ := table.Dialect()
+	r := csv.NewDialectReader(strings.NewReader("a,NA\nb,c\n"), d)
+	records, err := r.ReadAll()
+	if expected := [][]string{{"a", "NA"}, {"b", "c"}}; err != nil || !reflect.DeepEqual(records, expected) {
+		t.Error("Unexpected output:", records, err)
+	}
+}
+
+func TestTableDialectExport(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(dialect.FromDialect(csv.Dialect{}))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := `{"delimiter":",","doubleQuote":true,"quoteChar":"\"","lineTerminator":"\n","commentPrefix":"#","encoding":"utf-8"}`
+	if string(b) != expected {
+		t.Error("Unexpected output:", string(b), "Expected:", expected)
+	}
+
+	if b, _ := json.Marshal(dialect.FromDialect(csv.ExcelDialect)); strings.Contains(string(b), "commentPrefix") {
+		t.Error("Unexpected output:", string(b))
+	}
+
+	dialects := []csv.Dialect{
+		csv.ExcelDialect,
+		{Delimiter: '|', QuoteChar: '\'', DoubleQuote: csv.NoDoubleQuote, EscapeChar: '/', LineTerminator: "\n", Comment: ';', SkipInitialSpace: true, Encoding: csv.UTF16LE},
+	}
+	for _, d := range dialects {
+		converted, err := dialect.FromDialect(d).Dialect()
+		if err != nil {
+			t.Error("Unexpected error:", err)
+			continue
+		}
+		if d.Comment == 0 {
+			d.Comment = csv.DefaultComment
+		}
+		// Quoting modes can't be described.
+		d.Quoting = csv.QuoteDefault
+		if !reflect.DeepEqual(converted, d) {
+			t.Errorf("Unexpected output: %#v Expected: %#v", converted, d)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding defines the character encoding of a CSV file. Reader decodes it to
+// UTF-8 and Writer encodes UTF-8 to it.
+type Encoding int
+
+// Values Encoding can take.
+const (
+	UTF8        Encoding = iota // UTF-8. Nothing is transcoded.
+	UTF16LE                     // UTF-16, little endian.
+	UTF16BE                     // UTF-16, big endian.
+	Latin1                      // ISO 8859-1.
+	Windows1252                 // Windows code page 1252, a superset of ISO 8859-1.
+)
+
+var encodingNames = map[Encoding]string{
+	UTF8:        "utf-8",
+	UTF16LE:     "utf-16le",
+	UTF16BE:     "utf-16be",
+	Latin1:      "iso-8859-1",
+	Windows1252: "windows-1252",
+}
+
+func (e Encoding) String() string {
+	if name, ok := encodingNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// Alternative names accepted by UnmarshalText.
+var encodingAliases = map[string]Encoding{
+	"utf8":    UTF8,
+	"utf16le": UTF16LE,
+	"utf16be": UTF16BE,
+	"latin1":  Latin1,
+	"cp1252":  Windows1252,
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e Encoding) MarshalText() ([]byte, error) {
+	return marshalName(encodingNames, e, "encoding")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Names are the ones
+// returned by String, and aliases such as "utf8" and "latin1". They are
+// matched case insensitively.
+func (e *Encoding) UnmarshalText(text []byte) error {
+	if alias, ok := encodingAliases[strings.ToLower(string(text))]; ok {
+		*e = alias
+		return nil
+	}
+	return unmarshalName(encodingNames, e, text, "encoding")
+}
+
+// InvalidPolicy defines how byte sequences that are invalid in an Encoding,
+// and characters that can't be represented in it, are handled.
+type InvalidPolicy int
+
+// Values InvalidPolicy can take.
+const (
+	// Replace with utf8.RuneError when decoding. When encoding, '?' is used
+	// for single byte encodings.
+	InvalidReplace InvalidPolicy = iota
+	// Fail with an *EncodingError.
+	InvalidError
+	// Keep the original bytes where possible. Bytes undefined in Windows1252
+	// are decoded as the C1 control character with the same value, and vice
+	// versa. Invalid UTF-8 written to a single byte encoding is written as is.
+	// Anything else is replaced.
+	InvalidPassThrough
+)
+
+var invalidPolicyNames = map[InvalidPolicy]string{
+	InvalidReplace:     "replace",
+	InvalidError:       "error",
+	InvalidPassThrough: "passthrough",
+}
+
+func (p InvalidPolicy) String() string {
+	if name, ok := invalidPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("InvalidPolicy(%d)", int(p))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p InvalidPolicy) MarshalText() ([]byte, error) {
+	return marshalName(invalidPolicyNames, p, "invalid policy")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Names are the ones
+// returned by String, and are matched case insensitively.
+func (p *InvalidPolicy) UnmarshalText(text []byte) error {
+	return unmarshalName(invalidPolicyNames, p, text, "invalid policy")
+}
+
+// An EncodingError is returned when a byte sequence is invalid in an Encoding,
+// or a character can't be represented in it, and InvalidError is used.
+type EncodingError struct {
+	Encoding Encoding
+	// Offset of the invalid byte sequence. When decoding, it is counted from the
+	// start of the encoded input. When encoding, it is counted from the start of
+	// the UTF-8 encoded output before transcoding.
+	Offset int64
+}
+
+func (e *EncodingError) Error() string {
+	return fmt.Sprintf("csv: invalid %s at byte offset %d", e.Encoding, e.Offset)
+}
+
+// ByteOrderMark defines which byte order mark, if any, a Writer writes at the
+// start of its output.
+type ByteOrderMark int
+
+// Values ByteOrderMark can take.
+const (
+	NoByteOrderMark      ByteOrderMark = iota // No byte order mark.
+	UTF8ByteOrderMark                         // The bytes EF BB BF. Writing fails unless Dialect.Encoding is UTF8.
+	UTF16LEByteOrderMark                      // The bytes FF FE. Output is encoded as UTF-16LE if Dialect.Encoding is UTF8. Writing fails unless it is UTF8 or UTF16LE.
+)
+
+const byteOrderMark = '\uFEFF'
+
+// Windows-1252 characters for the bytes 0x80-0x9F. Zero marks undefined bytes.
+var windows1252 = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+// decodeByte decodes a byte of a single byte encoding. ok is false if it's
+// undefined, in which case the C1 control character with the same value is
+// returned.
+func (e Encoding) decodeByte(b byte) (r rune, ok bool) {
+	if e == Windows1252 && b >= 0x80 && b < 0xA0 {
+		if r := windows1252[b-0x80]; r != 0 {
+			return r, true
+		}
+		return rune(b), false
+	}
+	return rune(b), true
+}
+
+// encodeByte encodes r using a single byte encoding. Only the undefined
+// Windows-1252 bytes are encoded if passThrough is true.
+func (e Encoding) encodeByte(r rune, passThrough bool) (b byte, ok bool) {
+	if e == Windows1252 && r >= 0x80 {
+		for i, c := range windows1252 {
+			if c == r || (c == 0 && passThrough && rune(0x80+i) == r) {
+				return byte(0x80 + i), true
+			}
+		}
+		if r < 0xA0 {
+			return '?', false
+		}
+	}
+	if r < 0x100 {
+		return byte(r), true
+	}
+	return '?', false
+}
+
+func (e Encoding) isUTF16() bool {
+	return e == UTF16LE || e == UTF16BE
+}
+
+func (e Encoding) appendUnit(b []byte, r rune) []byte {
+	if e == UTF16LE {
+		return append(b, byte(r), byte(r>>8))
+	}
+	return append(b, byte(r>>8), byte(r))
+}
+
+func (e Encoding) unit(b []byte) rune {
+	if e == UTF16LE {
+		return rune(b[0]) | rune(b[1])<<8
+	}
+	return rune(b[0])<<8 | rune(b[1])
+}
+
+// Encodes UTF-8 written to it using an Encoding and writes it to w. Runes
+// split between two calls to Write are handled.
+type encodingWriter struct {
+	w       io.Writer
+	enc     Encoding
+	policy  InvalidPolicy
+	pending []byte
+	buf     []byte
+	offset  int64
+}
+
+func newEncodingWriter(w io.Writer, enc Encoding, policy InvalidPolicy) *encodingWriter {
+	return &encodingWriter{
+		w:      w,
+		enc:    enc,
+		policy: policy,
+	}
+}
+
+func (e *encodingWriter) Write(p []byte) (int, error) {
+	data := append(e.pending, p...)
+	e.buf = e.buf[:0]
+	var err error
+	for len(data) > 0 && utf8.FullRune(data) {
+		r, size := utf8.DecodeRune(data)
+		valid := r != utf8.RuneError || size > 1
+		if e.buf, err = e.appendRune(e.buf, r, valid, data[:size]); err != nil {
+			break
+		}
+		data = data[size:]
+		e.offset += int64(size)
+	}
+	e.pending = append(e.pending[:0], data...)
+	if _, werr := e.w.Write(e.buf); werr != nil {
+		return 0, werr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (e *encodingWriter) appendRune(b []byte, r rune, valid bool, raw []byte) ([]byte, error) {
+	if e.enc.isUTF16() {
+		if !valid && e.policy == InvalidError {
+			return b, &EncodingError{Encoding: e.enc, Offset: e.offset}
+		}
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			return e.enc.appendUnit(e.enc.appendUnit(b, r1), r2), nil
+		}
+		return e.enc.appendUnit(b, r), nil
+	}
+
+	c, ok := e.enc.encodeByte(r, e.policy == InvalidPassThrough)
+	if valid && ok {
+		return append(b, c), nil
+	}
+	switch e.policy {
+	case InvalidError:
+		return b, &EncodingError{Encoding: e.enc, Offset: e.offset}
+	case InvalidPassThrough:
+		if !valid {
+			return append(b, raw...), nil
+		}
+	}
+	return append(b, '?'), nil
+}
+
+// Decodes input read from r using an Encoding into UTF-8.
+type decodingReader struct {
+	r      io.Reader
+	enc    Encoding
+	policy InvalidPolicy
+	in     []byte
+	out    []byte
+	offset int64
+	err    error
+	// Number of invalid bytes to skip when resuming after an *EncodingError.
+	skip int
+}
+
+func newDecodingReader(r io.Reader, enc Encoding, policy InvalidPolicy) *decodingReader {
+	return &decodingReader{
+		r:      r,
+		enc:    enc,
+		policy: policy,
+		in:     make([]byte, 0, 4096),
+	}
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.fill()
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// resume makes decoding continue after the invalid bytes that caused an
+// *EncodingError.
+func (d *decodingReader) resume() {
+	if _, ok := d.err.(*EncodingError); ok {
+		d.err = nil
+	}
+}
+
+func (d *decodingReader) fill() {
+	if d.skip > 0 {
+		d.in = d.in[:copy(d.in, d.in[d.skip:])]
+		d.offset += int64(d.skip)
+		d.skip = 0
+	}
+	n, err := d.r.Read(d.in[len(d.in):cap(d.in)])
+	d.in = d.in[:len(d.in)+n]
+	d.err = err
+
+	d.out = d.out[:0]
+	var consumed int
+	if d.enc.isUTF16() {
+		consumed = d.decodeUTF16()
+	} else {
+		consumed = d.decodeSingleByte()
+	}
+	d.offset += int64(consumed)
+	d.in = d.in[:copy(d.in, d.in[consumed:])]
+}
+
+// invalid handles an invalid byte sequence of size bytes and returns whether
+// decoding can continue.
+func (d *decodingReader) invalid(offset, size int, passThrough rune) bool {
+	switch d.policy {
+	case InvalidError:
+		d.err = &EncodingError{Encoding: d.enc, Offset: d.offset + int64(offset)}
+		d.skip = size
+		return false
+	case InvalidPassThrough:
+		d.out = appendRune(d.out, passThrough)
+	default:
+		d.out = appendRune(d.out, utf8.RuneError)
+	}
+	return true
+}
+
+func (d *decodingReader) decodeSingleByte() int {
+	for i, b := range d.in {
+		r, ok := d.enc.decodeByte(b)
+		if !ok {
+			if !d.invalid(i, 1, r) {
+				return i
+			}
+			continue
+		}
+		d.out = appendRune(d.out, r)
+	}
+	return len(d.in)
+}
+
+// Unpaired surrogates and a trailing odd byte are invalid. They can't be
+// passed through.
+func (d *decodingReader) decodeUTF16() int {
+	in := d.in
+	for len(in) >= 2 {
+		r := d.enc.unit(in)
+		if utf16.IsSurrogate(r) {
+			if len(in) < 4 && d.err == nil {
+				// Wait for the second half of the surrogate pair.
+				break
+			}
+			if len(in) >= 4 {
+				if pair := utf16.DecodeRune(r, d.enc.unit(in[2:])); pair != utf8.RuneError {
+					d.out = appendRune(d.out, pair)
+					in = in[4:]
+					continue
+				}
+			}
+			if !d.invalid(len(d.in)-len(in), 2, utf8.RuneError) {
+				return len(d.in) - len(in)
+			}
+			in = in[2:]
+			continue
+		}
+		d.out = appendRune(d.out, r)
+		in = in[2:]
+	}
+	if len(in) == 1 && d.err != nil {
+		if !d.invalid(len(d.in)-len(in), 1, utf8.RuneError) {
+			return len(d.in) - len(in)
+		}
+		in = in[1:]
+	}
+	return len(d.in) - len(in)
+}
+
+func appendRune(b []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(b, buf[:n]...)
+}
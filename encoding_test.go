@@ -0,0 +1,117 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		enc     Encoding
+		input   string
+		encoded string
+	}{
+		{UTF16LE, "a,€😀\n", "a\x00,\x00\xac\x20\x3d\xd8\x00\xde\n\x00"},
+		{UTF16BE, "a,€😀\n", "\x00a\x00,\x20\xac\xd8\x3d\xde\x00\x00\n"},
+		{Latin1, "a,åÿ\n", "a,\xe5\xff\n"},
+		{Windows1252, "a,€Šå\n", "a,\x80\x8a\xe5\n"},
+	}
+	for _, test := range tests {
+		b := new(bytes.Buffer)
+		w := newEncodingWriter(b, test.enc, InvalidError)
+		// Writing byte by byte makes sure runes split between writes are handled.
+		for i := 0; i < len(test.input); i++ {
+			if _, err := w.Write([]byte{test.input[i]}); err != nil {
+				t.Fatal(test.enc, "Unexpected error:", err)
+			}
+		}
+		if s := b.String(); s != test.encoded {
+			t.Errorf("%s: Unexpected output: %q Expected: %q", test.enc, s, test.encoded)
+		}
+
+		decoded, err := ioutil.ReadAll(newDecodingReader(iotest.OneByteReader(b), test.enc, InvalidError))
+		if err != nil {
+			t.Fatal(test.enc, "Unexpected error:", err)
+		}
+		if s := string(decoded); s != test.input {
+			t.Errorf("%s: Unexpected output: %q Expected: %q", test.enc, s, test.input)
+		}
+	}
+}
+
+func TestDecodingInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		enc      Encoding
+		policy   InvalidPolicy
+		input    string
+		expected string
+	}{
+		// An unpaired high surrogate, followed by "a" and an odd byte.
+		{UTF16LE, InvalidReplace, "\x00\xd8a\x00b", "�a�"},
+		{UTF16LE, InvalidPassThrough, "\x00\xd8a\x00b", "�a�"},
+		{Windows1252, InvalidReplace, "a\x81b", "a�b"},
+		{Windows1252, InvalidPassThrough, "a\x81b", "a\u0081b"},
+	}
+	for _, test := range tests {
+		decoded, err := ioutil.ReadAll(newDecodingReader(strings.NewReader(test.input), test.enc, test.policy))
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if s := string(decoded); s != test.expected {
+			t.Errorf("%s: Unexpected output: %q Expected: %q", test.enc, s, test.expected)
+		}
+	}
+
+	decoded, err := ioutil.ReadAll(newDecodingReader(strings.NewReader("ab\x9d"), Windows1252, InvalidError))
+	if expected := (&EncodingError{Encoding: Windows1252, Offset: 2}); !reflect.DeepEqual(err, expected) {
+		t.Errorf("Unexpected error: %v Expected: %v", err, expected)
+	}
+	if s := string(decoded); s != "ab" {
+		t.Errorf("Unexpected output: %q", s)
+	}
+}
+
+func TestEncodingInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		enc      Encoding
+		policy   InvalidPolicy
+		input    string
+		expected string
+	}{
+		{Latin1, InvalidReplace, "a€\xff", "a??"},
+		{Latin1, InvalidPassThrough, "a€\xff", "a?\xff"},
+		{Windows1252, InvalidPassThrough, "\u0081\u0080", "\x81?"},
+		{UTF16LE, InvalidReplace, "\xff", "\xfd\xff"},
+	}
+	for _, test := range tests {
+		b := new(bytes.Buffer)
+		if _, err := newEncodingWriter(b, test.enc, test.policy).Write([]byte(test.input)); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if s := b.String(); s != test.expected {
+			t.Errorf("%s: Unexpected output: %q Expected: %q", test.enc, s, test.expected)
+		}
+	}
+
+	b := new(bytes.Buffer)
+	_, err := newEncodingWriter(b, Latin1, InvalidError).Write([]byte("ab€"))
+	if expected := (&EncodingError{Encoding: Latin1, Offset: 2}); !reflect.DeepEqual(err, expected) {
+		t.Errorf("Unexpected error: %v Expected: %v", err, expected)
+	}
+	if s := b.String(); s != "ab" {
+		t.Errorf("Unexpected output: %q", s)
+	}
+}
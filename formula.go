@@ -0,0 +1,88 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormulaEscapeMode defines how fields that a spreadsheet application would
+// interpret as formulas are neutralised, to protect against CSV injection.
+// See https://owasp.org/www-community/attacks/CSV_Injection.
+type FormulaEscapeMode int
+
+// Values FormulaEscapeMode can take.
+const (
+	FormulaEscapeNone         FormulaEscapeMode = iota // Fields are written as is.
+	FormulaEscapePrefix                                // Fields are prefixed with a single quote.
+	FormulaEscapeQuotedPrefix                          // Fields are prefixed with a single quote and always quoted.
+)
+
+var formulaEscapeModeNames = map[FormulaEscapeMode]string{
+	FormulaEscapeNone:         "none",
+	FormulaEscapePrefix:       "prefix",
+	FormulaEscapeQuotedPrefix: "quoted-prefix",
+}
+
+func (m FormulaEscapeMode) String() string {
+	if name, ok := formulaEscapeModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("FormulaEscapeMode(%d)", int(m))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m FormulaEscapeMode) MarshalText() ([]byte, error) {
+	return marshalName(formulaEscapeModeNames, m, "formula escape mode")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Names are the ones
+// returned by String, and are matched case insensitively.
+func (m *FormulaEscapeMode) UnmarshalText(text []byte) error {
+	return unmarshalName(formulaEscapeModeNames, m, text, "formula escape mode")
+}
+
+// Characters that make a spreadsheet application interpret a field as a
+// formula.
+const formulaTriggers = "=+-@\t\r"
+
+const formulaEscape = '\''
+
+// Returns whether a field starts with zero or more formula escape characters
+// followed by a formula trigger. Such fields are escaped by adding another
+// escape character, which makes escaping reversible.
+func needsFormulaEscape(field string) bool {
+	field = strings.TrimLeft(field, string(formulaEscape))
+	return len(field) > 0 && strings.IndexByte(formulaTriggers, field[0]) >= 0
+}
+
+func (d *Dialect) formulaEscapeApplies(exempt []int, column int) bool {
+	if d.FormulaEscape == FormulaEscapeNone {
+		return false
+	}
+	for _, c := range exempt {
+		if c == column {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeFormula returns the field to write and whether it must be quoted.
+// Columns in exempt are left as is.
+func (d *Dialect) escapeFormula(exempt []int, column int, field string) (string, bool) {
+	if !d.formulaEscapeApplies(exempt, column) || !needsFormulaEscape(field) {
+		return field, false
+	}
+	return string(formulaEscape) + field, d.FormulaEscape == FormulaEscapeQuotedPrefix
+}
+
+// unescapeFormula reverses escapeFormula.
+func (d *Dialect) unescapeFormula(exempt []int, column int, field string) string {
+	if !d.formulaEscapeApplies(exempt, column) || len(field) == 0 || field[0] != formulaEscape || !needsFormulaEscape(field[1:]) {
+		return field
+	}
+	return field[1:]
+}
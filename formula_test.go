@@ -0,0 +1,70 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFormulaEscape(t *testing.T) {
+	t.Parallel()
+
+	record := []string{"=1+2", "@SUM(A1)", "-5", "'=x", "'a", "a=b", "\tx", ""}
+	tests := []struct {
+		dialect  Dialect
+		exempt   []int
+		expected string
+	}{
+		{
+			Dialect{FormulaEscape: FormulaEscapePrefix},
+			nil,
+			"'=1+2,'@SUM(A1),'-5,''=x,'a,a=b,'\tx,\n",
+		},
+		{
+			Dialect{FormulaEscape: FormulaEscapeQuotedPrefix},
+			[]int{2},
+			"\"'=1+2\",\"'@SUM(A1)\",-5,\"''=x\",'a,a=b,\"'\tx\",\n",
+		},
+		{
+			Dialect{FormulaEscape: FormulaEscapePrefix, Quoting: QuoteAll},
+			[]int{0, 2},
+			"\"=1+2\",\"'@SUM(A1)\",\"-5\",\"''=x\",\"'a\",\"a=b\",\"'\tx\",\"\"\n",
+		},
+	}
+	for _, test := range tests {
+		b := new(bytes.Buffer)
+		w := NewDialectWriter(b, test.dialect)
+		w.FormulaExemptColumns = test.exempt
+		w.Write(record)
+		w.Flush()
+		if s := b.String(); s != test.expected {
+			t.Errorf("Unexpected output: %q Expected: %q", s, test.expected)
+		}
+
+		r := NewDialectReader(b, test.dialect)
+		r.FormulaExemptColumns = test.exempt
+		read, err := r.Read()
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if !reflect.DeepEqual(read, record) {
+			t.Errorf("Unexpected record: %q Expected: %q", read, record)
+		}
+	}
+}
+
+func TestFormulaEscapeNotStrippedByDefault(t *testing.T) {
+	t.Parallel()
+
+	b := bytes.NewBufferString("'=1+2\n")
+	read, err := NewReader(b).Read()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if expected := []string{"'=1+2"}; !reflect.DeepEqual(read, expected) {
+		t.Errorf("Unexpected record: %q Expected: %q", read, expected)
+	}
+}
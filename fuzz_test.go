@@ -0,0 +1,174 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Splits fuzzing data into records, using ASCII record and unit separators.
+func fuzzRecords(data string) [][]string {
+	var records [][]string
+	for _, line := range strings.Split(data, "\x1e") {
+		records = append(records, strings.Split(line, "\x1f"))
+	}
+	return records
+}
+
+// Returns whether a Dialect is unambiguous enough for its output to be read
+// back. Special characters must be distinct from each other and must not be
+// part of the line terminator.
+func roundTripSafe(d Dialect) bool {
+	d.setDefaults()
+	special := []rune{d.Delimiter, d.QuoteChar, d.EscapeChar, d.Comment}
+	for i, r := range special {
+		if !utf8.ValidRune(r) || r == utf8.RuneError || r == '\r' || r == '\n' {
+			return false
+		}
+		if strings.ContainsRune(d.LineTerminator, r) {
+			return false
+		}
+		for _, other := range special[:i] {
+			if r == other {
+				return false
+			}
+		}
+	}
+	return utf8.ValidString(d.LineTerminator) && d.Quoting != QuoteNone
+}
+
+// A record without fields is written as an empty line, which is read back as a
+// single empty field.
+func normalizeEmptyRecords(records [][]string) [][]string {
+	normalized := make([][]string, len(records))
+	for i, record := range records {
+		if len(record) == 0 {
+			record = []string{""}
+		}
+		normalized[i] = record
+	}
+	return normalized
+}
+
+func testRoundTrip(t *testing.T, dialect Dialect, records [][]string) {
+	b := new(bytes.Buffer)
+	w := NewDialectWriter(b, dialect)
+	if err := w.WriteAll(records); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	written := b.String()
+
+	read, err := NewDialectReader(b, dialect).ReadAll()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if expected := normalizeEmptyRecords(records); !reflect.DeepEqual(expected, read) {
+		t.Errorf("Round trip failed.\ndialect=%+v\nwritten=%q\nread=%q\nexpected=%q", dialect, written, read, expected)
+	}
+}
+
+func FuzzDialectRoundTrip(f *testing.F) {
+	f.Add("a\x1fb\x1ec", ',', '"', '\\', "\n", uint8(QuoteMinimal), true)
+	f.Add(" 1\x1f2.5\x1f\x1e", ';', '\'', '/', "\r\n", uint8(QuoteNonNumeric), false)
+	f.Add("a\tb\x1f\x1e\x1f", '\t', '"', '\\', "\n", uint8(QuoteNonNumericNonEmpty), false)
+	f.Add("|\x1f'", '|', '\'', '!', "$$", uint8(QuoteAll), true)
+	f.Fuzz(func(t *testing.T, data string, delimiter, quoteChar, escapeChar rune, lt string, quoting uint8, doubleQuote bool) {
+		dialect := Dialect{
+			Delimiter:      delimiter,
+			QuoteChar:      quoteChar,
+			EscapeChar:     escapeChar,
+			LineTerminator: lt,
+			Quoting:        QuoteMode(quoting % (QuoteNone + 1)),
+			DoubleQuote:    NoDoubleQuote,
+			OnInvalid:      InvalidPassThrough,
+		}
+		if doubleQuote {
+			dialect.DoubleQuote = DoDoubleQuote
+		}
+		if !roundTripSafe(dialect) {
+			t.Skip("Ambiguous dialect.")
+		}
+		testRoundTrip(t, dialect, fuzzRecords(data))
+	})
+}
+
+// Reading arbitrary input must never panic or loop forever.
+func FuzzReader(f *testing.F) {
+	f.Add([]byte("a,b,c\n"), ',', '"', '\\', "\n", false)
+	f.Add([]byte("\"a\"\"b\",\"c\n#d\n"), ',', '"', '\\', "\n", true)
+	f.Add([]byte("  #\r\n\"\\\"a\"x,\r\n"), ',', '"', '\\', "\r\n", false)
+	f.Add([]byte("\xff\"\xfe\",\xef\xbb\xbf"), ',', '"', '\\', "\n", false)
+	f.Fuzz(func(t *testing.T, data []byte, delimiter, quoteChar, escapeChar rune, lt string, doubleQuote bool) {
+		dialect := Dialect{
+			Delimiter:      delimiter,
+			QuoteChar:      quoteChar,
+			EscapeChar:     escapeChar,
+			LineTerminator: lt,
+			DoubleQuote:    NoDoubleQuote,
+		}
+		if doubleQuote {
+			dialect.DoubleQuote = DoDoubleQuote
+		}
+		// Unlike the round trip tests, ambiguous dialects are read as well.
+		NewDialectReader(bytes.NewReader(data), dialect).ReadAll()
+
+		r := NewDialectReader(bytes.NewReader(data), dialect)
+		r.FieldCount = 2
+		r.DeadLetter = io.Discard
+		if _, err := r.ReadAll(); err != nil {
+			t.Error("Unexpected error:", err)
+		}
+	})
+}
+
+// The default dialect must be compatible with encoding/csv in both
+// directions.
+func FuzzEncodingCSVDifferential(f *testing.F) {
+	f.Add("a\x1fb\x1ec")
+	f.Add("\x1f\x1e\x1e \"\x1f\\")
+	f.Add("a\nb\x1f\"\"\x1f#")
+	f.Fuzz(func(t *testing.T, data string) {
+		if !utf8.ValidString(data) {
+			t.Skip("encoding/csv does not replace invalid UTF-8.")
+		}
+		records := fuzzRecords(data)
+
+		// encoding/csv reads "\r\n" inside quoted fields as "\n".
+		if !strings.Contains(data, "\r") {
+			b := new(bytes.Buffer)
+			w := NewWriter(b)
+			w.WriteAll(records)
+			r := csv.NewReader(b)
+			r.FieldsPerRecord = -1
+			read, err := r.ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if !reflect.DeepEqual(records, read) {
+				t.Errorf("encoding/csv could not read Writer output.\nread=%q\nexpected=%q", read, records)
+			}
+		}
+
+		// encoding/csv does not quote fields starting with a comment character.
+		if !strings.Contains("\x1e"+data, "\x1e"+string(DefaultComment)) {
+			b := new(bytes.Buffer)
+			w := csv.NewWriter(b)
+			w.WriteAll(records)
+			written := b.String()
+			read, err := NewReader(b).ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if !reflect.DeepEqual(records, read) {
+				t.Errorf("Could not read encoding/csv output.\nwritten=%q\nread=%q\nexpected=%q", written, read, records)
+			}
+		}
+	})
+}
@@ -1,3 +1,3 @@
 module github.com/JensRantil/go-csv
 
-go 1.14
+go 1.18
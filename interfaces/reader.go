@@ -5,6 +5,10 @@
 // Can be used to easily use go-csv as a drop-in replacement for the latter.
 package interfaces
 
+import (
+	"context"
+)
+
 // A helper interface for a general CSV reader. Conforms to encoding/csv Reader
 // in the standard Go library as well as the Reader implemented by go-csv.
 type Reader interface {
@@ -18,3 +22,12 @@ type Reader interface {
 	// error to be reported.
 	ReadAll() (records [][]string, err error)
 }
+
+// A helper interface for a CSV reader that supports cancellation using a
+// context. Implemented by the Reader in go-csv, but not by encoding/csv.
+type ContextReader interface {
+	Reader
+
+	// ReadContext is like Read, but returns ctx.Err() if ctx is done.
+	ReadContext(ctx context.Context) (record []string, err error)
+}
@@ -5,6 +5,7 @@ package interfaces
 
 import (
 	"bytes"
+	"context"
 	oldcsv "encoding/csv"
 	thiscsv "github.com/JensRantil/go-csv"
 	"testing"
@@ -21,3 +22,14 @@ func TestReaderInterface(t *testing.T) {
 	// To get rid of compile-time warning that this variable is not used.
 	iface.Read()
 }
+
+func TestContextReaderInterface(t *testing.T) {
+	t.Parallel()
+
+	var iface ContextReader
+	iface = thiscsv.NewReader(new(bytes.Buffer))
+	iface = thiscsv.NewDialectReader(new(bytes.Buffer), thiscsv.Dialect{})
+
+	// To get rid of compile-time warning that this variable is not used.
+	iface.ReadContext(context.Background())
+}
@@ -6,19 +6,139 @@ package csv
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"unicode/utf8"
 )
 
+// A ParseError is returned for parsing errors. Line numbers are 1-indexed and
+// count newline characters, regardless of Dialect.LineTerminator. Columns are
+// 1-indexed byte offsets within the line.
+type ParseError struct {
+	StartLine int   // Line where the record starts.
+	Line      int   // Line where the error occurred.
+	Column    int   // Column where the error occurred.
+	Err       error // The actual error.
+}
+
+func (e *ParseError) Error() string {
+	if e.StartLine != e.Line {
+		return fmt.Sprintf("record on line %d; parse error on line %d, column %d: %v", e.StartLine, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("parse error on line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returned in ParseError.Err.
+var (
+	// Returned for invalid UTF-8 if Dialect.OnInvalid is InvalidError.
+	ErrInvalidUTF8 = errors.New("invalid UTF-8")
+	// Returned when exceeding Reader.MaxFieldBytes.
+	ErrFieldTooLarge = errors.New("field too large")
+	// Returned when exceeding Reader.MaxRecordBytes.
+	ErrRecordTooLarge = errors.New("record too large")
+	// Returned when exceeding Reader.MaxFieldsPerRecord.
+	ErrTooManyFields = errors.New("too many fields")
+	// Returned when exceeding Reader.MaxRecords.
+	ErrTooManyRecords = errors.New("too many records")
+	// Returned for a quoted field that is followed by something other than a
+	// delimiter or line terminator, or that isn't terminated. Only if
+	// Reader.StrictQuotes is set or error recovery is enabled.
+	ErrQuote = errors.New("extraneous or missing quote in quoted field")
+	// Returned when a record doesn't have Reader.FieldCount fields.
+	ErrFieldCount = errors.New("wrong number of fields")
+)
+
 // A Reader reads records from a CSV-encoded file.
 //
 // Can be created by calling either NewReader or using NewDialectReader.
 type Reader struct {
+	// Limits that protect against malicious or broken input, such as a
+	// multi-gigabyte field caused by an unterminated quote. A limit is only
+	// enforced if positive. Exceeding a limit makes Read fail with a
+	// *ParseError as soon as it is detected. The record is nil if a field
+	// exceeds MaxFieldBytes or MaxRecordBytes.
+
+	// Maximum number of bytes in a field.
+	MaxFieldBytes int
+	// Maximum number of bytes in the fields of a record, not counting
+	// delimiters and quotes.
+	MaxRecordBytes int
+	// Maximum number of fields in a record.
+	MaxFieldsPerRecord int
+	// Maximum number of records to read.
+	MaxRecords int
+
+	// Number of fields every record must have. Only enforced if positive, in
+	// which case Read returns records with another number of fields together
+	// with a *ParseError. Unlike encoding/csv's FieldsPerRecord, zero does not
+	// take the number of fields from the first record.
+	FieldCount int
+
+	// Zero-based columns Dialect.FormulaEscape is not reversed in. Should be
+	// the Writer.FormulaExemptColumns the input was written with.
+	FormulaExemptColumns []int
+
+	// If true, a quoted field followed by something other than a delimiter or
+	// line terminator, or that isn't terminated, is an error. Otherwise, like
+	// in earlier versions, the record ends after the closing quote and the
+	// next record starts with what follows it, and an unterminated field ends
+	// at the end of the input. Always enabled if ErrorHandler or DeadLetter is
+	// set.
+	StrictQuotes bool
+
+	// If true, the text of each record is kept. See RecordText.
+	KeepRecordText bool
+
+	// Error recovery for messy input. If ErrorHandler or DeadLetter is set,
+	// Read skips malformed records instead of failing: records with bad
+	// quoting, the wrong number of fields, or invalid input when
+	// Dialect.OnInvalid is InvalidError. Reading resumes after the next line
+	// terminator. A skipped record is passed on as its text, like RecordText
+	// returns it: including its line terminator, and decoded to UTF-8 if
+	// Dialect.Encoding isn't UTF8.
+
+	// Called for each skipped record. text is only valid until the next call
+	// to Read.
+	ErrorHandler func(text []byte, err *ParseError)
+	// Receives the text of each skipped record.
+	DeadLetter io.Writer
+	// Maximum number of records to skip. Only enforced if positive. Read fails
+	// with the error of the first malformed record exceeding it.
+	MaxErrors int
+
 	opts                    Dialect
 	r                       *bufio.Reader
+	started                 bool
 	tmpBuf                  bytes.Buffer
 	optimizedDelimiter      []byte
+	optimizedQuoteChar      []byte
 	optimizedLineTerminator []byte
+
+	// Position of the next byte to read. column is 0-indexed.
+	line, column int
+	// Line where the current record starts.
+	recordLine int
+	// Number of records read, and bytes in the fields of the current record.
+	records, recordBytes int
+	// Position before, and size of, the last rune read. Used to unread it.
+	prevLine, prevColumn, lastSize int
+	// Whether the last rune read was invalid UTF-8, and its raw byte.
+	lastInvalid bool
+	invalidByte byte
+	// Text of the current record. Only kept if KeepRecordText is set or error
+	// recovery is enabled.
+	text []byte
+	// Number of malformed records skipped.
+	skipped int
+	// Decodes the input if it isn't UTF-8.
+	decoder *decodingReader
 }
 
 // Creates a reader that conforms to RFC 4180 and behaves identical as a
@@ -32,14 +152,32 @@ func NewReader(r io.Reader) *Reader {
 // Create a custom CSV reader.
 func NewDialectReader(r io.Reader, opts Dialect) *Reader {
 	opts.setDefaults()
+	var decoder *decodingReader
+	if opts.Encoding != UTF8 {
+		decoder = newDecodingReader(r, opts.Encoding, opts.OnInvalid)
+		r = decoder
+	}
 	return &Reader{
 		opts:                    opts,
-		r:                       bufio.NewReader(r),
+		r:                       newBufferedReader(r, opts.LineTerminator),
+		decoder:                 decoder,
 		optimizedDelimiter:      []byte(string(opts.Delimiter)),
+		optimizedQuoteChar:      []byte(string(opts.QuoteChar)),
 		optimizedLineTerminator: []byte(opts.LineTerminator),
+		line:                    1,
 	}
 }
 
+// newBufferedReader returns a bufio.Reader that can peek at a whole line
+// terminator, however long it is.
+func newBufferedReader(r io.Reader, lineTerminator string) *bufio.Reader {
+	size := 4096
+	if len(lineTerminator) > size {
+		size = len(lineTerminator)
+	}
+	return bufio.NewReaderSize(r, size)
+}
+
 // ReadAll reads all the remaining records from r. Each record is a slice of
 // fields. A successful call returns err == nil, not err == EOF. Because
 // ReadAll is defined to read until EOF, it does not treat end of file as an
@@ -64,32 +202,174 @@ func (r *Reader) ReadAll() ([][]string, error) {
 // Read reads one record from r. The record is a slice of strings with each
 // string representing one field.
 func (r *Reader) Read() ([]string, error) {
+	for {
+		record, err := r.readRecord()
+		parseError, ok := err.(*ParseError)
+		if !ok || !r.recovering() || !isMalformed(parseError) {
+			return record, err
+		}
+		if r.MaxErrors > 0 && r.skipped >= r.MaxErrors {
+			return record, err
+		}
+		r.skipped++
+
+		// A record with the wrong number of fields has already been read to its
+		// end.
+		if parseError.Err != ErrFieldCount {
+			r.resumeDecoding(err)
+			if err := r.skipRecord(); err != nil && err != io.EOF {
+				return nil, r.wrapError(err)
+			}
+		}
+		if r.ErrorHandler != nil {
+			r.ErrorHandler(r.text, parseError)
+		}
+		if r.DeadLetter != nil {
+			if _, err := r.DeadLetter.Write(r.text); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// RecordText returns the text of the record last returned by Read, as it
+// appears in the input. It includes quotes, escape characters and the line
+// terminator, if any, but not preceding comments. A record spans several lines
+// if it has quoted fields containing newlines.
+// If Dialect.Encoding isn't UTF8, the text is decoded to UTF-8, so it is not
+// a copy of the input bytes.
+//
+// Returns nil unless KeepRecordText is set. The returned slice is only valid
+// until the next call to Read.
+func (r *Reader) RecordText() []byte {
+	if !r.KeepRecordText {
+		return nil
+	}
+	return r.text
+}
+
+// RecordLine returns the line where the record last returned by Read starts.
+// Lines are counted like in ParseError.
+func (r *Reader) RecordLine() int {
+	return r.recordLine
+}
+
+// recovering returns whether malformed records are skipped.
+func (r *Reader) recovering() bool {
+	return r.ErrorHandler != nil || r.DeadLetter != nil
+}
+
+// strictQuotes returns whether malformed quoted fields are errors.
+func (r *Reader) strictQuotes() bool {
+	return r.StrictQuotes || r.recovering()
+}
+
+// keepingText returns whether the text of records is kept.
+func (r *Reader) keepingText() bool {
+	return r.KeepRecordText || r.recovering()
+}
+
+// isMalformed returns whether a record can be skipped because of err.
+func isMalformed(err *ParseError) bool {
+	switch err.Err {
+	case ErrQuote, ErrFieldCount, ErrInvalidUTF8:
+		return true
+	}
+	_, ok := err.Err.(*EncodingError)
+	return ok
+}
+
+// resumeDecoding makes decoding continue after invalid input if err was caused
+// by it. It returns whether it did.
+func (r *Reader) resumeDecoding(err error) bool {
+	var encodingError *EncodingError
+	if r.decoder == nil || !errors.As(err, &encodingError) {
+		return false
+	}
+	r.decoder.resume()
+	return true
+}
+
+// skipRecord skips the rest of a malformed record, up to and including the
+// next line terminator. Invalid input is skipped as well.
+func (r *Reader) skipRecord() error {
+	for {
+		if err := r.skipLine(); !r.resumeDecoding(err) {
+			return err
+		}
+	}
+}
+
+// checkFieldCount checks a record against FieldCount.
+func (r *Reader) checkFieldCount(record []string) error {
+	if r.FieldCount > 0 && len(record) != r.FieldCount {
+		return &ParseError{StartLine: r.recordLine, Line: r.recordLine, Column: 1, Err: ErrFieldCount}
+	}
+	return nil
+}
+
+func (r *Reader) readRecord() ([]string, error) {
 	// TODO: Possible optimization; store the maximum number of columns for
 	// faster preallocation.
 	record := make([]string, 0, 2)
 
+	if !r.started {
+		r.started = true
+		r.skipByteOrderMark()
+	}
+	r.text = r.text[:0]
 	if err := r.skipComments(); err != nil {
-		return record, err
+		return record, r.wrapError(err)
+	}
+	r.recordLine = r.line
+	r.recordBytes = 0
+	r.text = r.text[:0]
+	if r.records++; r.MaxRecords > 0 && r.records > r.MaxRecords {
+		return record, r.errorAt(r.line, r.column, ErrTooManyRecords)
 	}
 
 	for {
+		if r.MaxFieldsPerRecord > 0 && len(record) == r.MaxFieldsPerRecord {
+			return record, r.errorAt(r.line, r.column, ErrTooManyFields)
+		}
 		field, err := r.readField()
-		record = append(record, field)
+		if parseError, ok := err.(*ParseError); ok && (parseError.Err == ErrFieldTooLarge || parseError.Err == ErrRecordTooLarge) {
+			// The field is cut short, and larger than allowed.
+			return nil, err
+		}
+		r.recordBytes += len(field)
+		record = append(record, r.opts.unescapeFormula(r.FormulaExemptColumns, len(record), field))
+		if err == io.EOF {
+			// The last record might not be followed by a line terminator. The next
+			// call will return io.EOF.
+			return record, r.checkFieldCount(record)
+		}
 		if err != nil {
-			return record, err
+			return record, r.wrapError(err)
 		}
 
 		if nextIsLineTerminator, _ := r.nextIsLineTerminator(); nextIsLineTerminator {
 			// Skipping so that next read call is good to go.
-			err = r.skipLineTerminator()
-			// Error is not expected since it should be in the Unreader buffer, but
-			// might as well return it just in case.
-			return record, err
+			if err = r.skipLineTerminator(); err != nil {
+				// Error is not expected since it should be in the Unreader buffer, but
+				// might as well return it just in case.
+				return record, err
+			}
+			return record, r.checkFieldCount(record)
 		}
 		nextIsDelimiter, err := r.nextIsDelimiter()
 		if !nextIsDelimiter {
-			// Herein lies the devil!
-			return record, err
+			// Only a quoted field can be followed by something else.
+			switch err {
+			case io.EOF:
+				return record, r.checkFieldCount(record)
+			case nil:
+				if !r.strictQuotes() {
+					return record, r.checkFieldCount(record)
+				}
+				return record, r.errorAt(r.line, r.column, ErrQuote)
+			}
+			return record, r.wrapError(err)
 		} else {
 			r.skipDelimiter()
 		}
@@ -99,23 +379,202 @@ func (r *Reader) Read() ([]string, error) {
 	return record, nil
 }
 
+// ReadContext is like Read, but returns ctx.Err() without reading anything if
+// ctx is done. Cancellation is only checked between records. To abandon a
+// read blocked on the underlying io.Reader, that reader must be closed; the
+// net/http server does so for request bodies.
+func (r *Reader) ReadContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.Read()
+}
+
+// Record is a record, or an error, sent by Reader.Records.
+type Record struct {
+	Fields []string
+	Err    error
+}
+
+// Records reads the remaining records in a separate goroutine and sends them on
+// the returned channel. The channel is closed after EOF, after the first error
+// has been sent, or when ctx is done. The goroutine exits once the channel is
+// closed, so either read until the channel is closed or cancel ctx. The Reader
+// must not be used by anything else until the channel is closed.
+func (r *Reader) Records(ctx context.Context) <-chan Record {
+	ch := make(chan Record)
+	go func() {
+		defer close(ch)
+		for {
+			fields, err := r.ReadContext(ctx)
+			if err == io.EOF {
+				return
+			}
+			select {
+			case ch <- Record{Fields: fields, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// skipByteOrderMark skips a byte order mark at the start of the input. If a
+// UTF-16 byte order mark is found when expecting UTF-8, the rest of the input
+// is decoded as UTF-16.
+func (r *Reader) skipByteOrderMark() {
+	switch r.opts.Encoding {
+	case UTF8, UTF16LE, UTF16BE:
+	default:
+		// Byte order marks are valid characters in single byte encodings.
+		return
+	}
+	// Byte order marks are not counted as part of the first line.
+	if nextBytes, _ := r.r.Peek(3); bytes.Equal(nextBytes, []byte{0xEF, 0xBB, 0xBF}) {
+		r.r.Discard(3)
+		return
+	}
+	if r.opts.Encoding != UTF8 {
+		return
+	}
+	nextBytes, _ := r.r.Peek(2)
+	switch {
+	case bytes.Equal(nextBytes, []byte{0xFF, 0xFE}):
+		r.r.Discard(2)
+		r.decoder = newDecodingReader(r.r, UTF16LE, r.opts.OnInvalid)
+		r.r = newBufferedReader(r.decoder, r.opts.LineTerminator)
+	case bytes.Equal(nextBytes, []byte{0xFE, 0xFF}):
+		r.r.Discard(2)
+		r.decoder = newDecodingReader(r.r, UTF16BE, r.opts.OnInvalid)
+		r.r = newBufferedReader(r.decoder, r.opts.LineTerminator)
+	}
+}
+
 func (r *Reader) readField() (string, error) {
+	if r.opts.SkipInitialSpace {
+		r.skipInitialSpace()
+	}
 	if islt, err := r.nextIsLineTerminator(); islt || err != nil {
 		return "", err
 	}
 
-	char, _, err := r.r.ReadRune()
+	// An invalid QuoteChar is encoded like the replacement character, but
+	// never matches it when reading the field.
+	if isQuote, _ := r.nextIsBytes(r.optimizedQuoteChar); isQuote && utf8.ValidRune(r.opts.QuoteChar) {
+		return r.readQuotedField()
+	}
+	return r.readUnquotedField()
+}
+
+// readRune reads the next rune and keeps track of the position in the input.
+// Invalid UTF-8 is handled according to Dialect.OnInvalid.
+func (r *Reader) readRune() (rune, error) {
+	char, size, err := r.r.ReadRune()
+	r.lastSize = size
 	if err != nil {
-		return "", err
+		return char, err
+	}
+	r.prevLine, r.prevColumn = r.line, r.column
+	if char == '\n' {
+		r.line++
+		r.column = 0
+	} else {
+		r.column += size
 	}
 
-	// Let the next individual reader functions handle this.
-	r.r.UnreadRune()
+	r.lastInvalid = char == utf8.RuneError && size == 1
+	if r.lastInvalid {
+		r.r.UnreadRune()
+		r.invalidByte, _ = r.r.ReadByte()
+	}
+	if r.keepingText() {
+		if r.lastInvalid {
+			r.text = append(r.text, r.invalidByte)
+		} else {
+			r.text = appendRune(r.text, char)
+		}
+	}
+	if r.lastInvalid && r.opts.OnInvalid == InvalidError {
+		return char, r.errorAt(r.prevLine, r.prevColumn, ErrInvalidUTF8)
+	}
+	return char, nil
+}
 
-	if char == r.opts.QuoteChar {
-		return r.readQuotedField()
+// unreadRune unreads the last rune read by readRune, if any.
+func (r *Reader) unreadRune() {
+	if r.lastSize > 0 {
+		if r.lastInvalid {
+			r.r.UnreadByte()
+		} else {
+			r.r.UnreadRune()
+		}
+		if r.keepingText() {
+			if r.lastInvalid {
+				r.text = r.text[:len(r.text)-1]
+			} else {
+				r.text = r.text[:len(r.text)-r.lastSize]
+			}
+		}
+		r.line, r.column = r.prevLine, r.prevColumn
+		r.lastSize = 0
+	}
+}
+
+// writeRune adds the last rune read by readRune to a field and checks that
+// the field and record don't become too large.
+func (r *Reader) writeRune(s *bytes.Buffer, char rune) error {
+	if r.lastInvalid && r.opts.OnInvalid == InvalidPassThrough {
+		s.WriteByte(r.invalidByte)
+	} else {
+		s.WriteRune(char)
+	}
+	if r.MaxFieldBytes > 0 && s.Len() > r.MaxFieldBytes {
+		return r.errorAt(r.prevLine, r.prevColumn, ErrFieldTooLarge)
+	}
+	if r.MaxRecordBytes > 0 && r.recordBytes+s.Len() > r.MaxRecordBytes {
+		return r.errorAt(r.prevLine, r.prevColumn, ErrRecordTooLarge)
+	}
+	return nil
+}
+
+// discard skips n bytes and keeps track of the position in the input.
+func (r *Reader) discard(n int) error {
+	nextBytes, _ := r.r.Peek(n)
+	if r.keepingText() {
+		r.text = append(r.text, nextBytes...)
+	}
+	for _, b := range nextBytes {
+		if b == '\n' {
+			r.line++
+			r.column = 0
+		} else {
+			r.column++
+		}
+	}
+	_, err := r.r.Discard(n)
+	return err
+}
+
+// errorAt returns a ParseError for a 0-indexed column.
+func (r *Reader) errorAt(line, column int, err error) error {
+	return &ParseError{
+		StartLine: r.recordLine,
+		Line:      line,
+		Column:    column + 1,
+		Err:       err,
 	}
-	return r.readUnquotedField()
+}
+
+// wrapError makes sure errors from decoding the input carry a position.
+func (r *Reader) wrapError(err error) error {
+	if encodingError, ok := err.(*EncodingError); ok {
+		return r.errorAt(r.line, r.column, encodingError)
+	}
+	return err
 }
 
 func (r *Reader) nextIsLineTerminator() (bool, error) {
@@ -129,66 +588,99 @@ func (r *Reader) nextIsDelimiter() (bool, error) {
 func (r *Reader) nextIsBytes(bs []byte) (bool, error) {
 	n := len(bs)
 	nextBytes, err := r.r.Peek(n)
+	if err == io.EOF && len(nextBytes) > 0 {
+		// The input ends with a prefix of bs. There's more to read.
+		err = nil
+	}
 	return bytes.Equal(nextBytes, bs), err
 }
 
 func (r *Reader) skipLineTerminator() error {
-	_, err := r.r.Discard(len(r.optimizedLineTerminator))
-	return err
+	return r.discard(len(r.optimizedLineTerminator))
 }
 
+// skipComments skips all lines starting with the comment character,
+// optionally preceded by spaces or tabs.
 func (r *Reader) skipComments() error {
-	var n = 1
-	var isComment bool
 	for {
-		nextBytes, err := r.r.Peek(n)
-		if err != nil {
+		isComment, err := r.nextIsComment()
+		if !isComment {
+			return err
+		}
+		if err := r.skipLine(); err != nil {
 			return err
 		}
+	}
+	//skip until LineTerminator
+	return nil
+}
 
-		switch rune(nextBytes[n-1]) {
-		case ' ', '\t': //skip space
-			if !isComment {
-				n += 1
-				continue
-			} else {
-				return nil
+func (r *Reader) nextIsComment() (bool, error) {
+	n := 0
+	for {
+		nextBytes, err := r.r.Peek(n + 1)
+		if err != nil {
+			if n > 0 {
+				// Leave the whitespace for readField to consume.
+				err = nil
 			}
+			return false, err
+		}
+		if c := nextBytes[n]; (c != ' ' && c != '\t') || r.isSpecialAt(n) {
+			break
+		}
+		n++
+	}
+	if !utf8.ValidRune(r.opts.Comment) {
+		// Such as NoComment.
+		return false, nil
+	}
+	nextBytes, _ := r.r.Peek(n + utf8.RuneLen(r.opts.Comment))
+	comment, _ := utf8.DecodeRune(nextBytes[n:])
+	return comment == r.opts.Comment, nil
+}
 
-		case r.opts.Comment:
-			_, err := r.r.Discard(n)
-			if err != nil {
-				return err
-			}
-			n = 1
-			isComment = true
+// isSpecialAt returns whether the delimiter, quote character or line
+// terminator starts n bytes ahead.
+func (r *Reader) isSpecialAt(n int) bool {
+	for _, special := range [][]byte{r.optimizedDelimiter, []byte(string(r.opts.QuoteChar)), r.optimizedLineTerminator} {
+		nextBytes, _ := r.r.Peek(n + len(special))
+		if len(nextBytes) > n && bytes.Equal(nextBytes[n:], special) {
+			return true
+		}
+	}
+	return false
+}
 
-		default:
-			if !isComment {
-				return nil
-			} else if nextIsLineTerminator, _ := r.nextIsLineTerminator(); nextIsLineTerminator {
-				err = r.skipLineTerminator()
-				if err != nil {
-					return err
-				}
-				isComment = false
-			} else if _, err := r.r.Discard(n); err != nil {
-				return err
-			}
-			n = 1 //after discard or skip LineTermintator, reset n
+// skipInitialSpace skips spaces that aren't part of a delimiter, quote
+// character or line terminator.
+func (r *Reader) skipInitialSpace() {
+	for {
+		if isSpace, _ := r.nextIsBytes([]byte{' '}); !isSpace || r.isSpecialAt(0) {
+			return
+		}
+		r.discard(1)
+	}
+}
+
+// skipLine skips everything up to and including the next line terminator.
+func (r *Reader) skipLine() error {
+	for {
+		if nextIsLineTerminator, _ := r.nextIsLineTerminator(); nextIsLineTerminator {
+			return r.skipLineTerminator()
+		}
+		if err := r.discard(1); err != nil {
+			return err
 		}
 	}
-	//skip until LineTerminator
-	return nil
 }
 
 func (r *Reader) skipDelimiter() error {
-	_, err := r.r.Discard(len(r.optimizedDelimiter))
-	return err
+	return r.discard(len(r.optimizedDelimiter))
 }
 
 func (r *Reader) readQuotedField() (string, error) {
-	char, _, err := r.r.ReadRune()
+	char, err := r.readRune()
 	if err != nil {
 		return "", err
 	}
@@ -199,43 +691,56 @@ func (r *Reader) readQuotedField() (string, error) {
 	s := &r.tmpBuf
 	defer r.tmpBuf.Reset() // TODO: Not using defer here is faster.
 	for {
-		char, _, err := r.r.ReadRune()
+		char, err := r.readRune()
+		if err == io.EOF && r.strictQuotes() {
+			return s.String(), r.errorAt(r.line, r.column, ErrQuote)
+		}
 		if err != nil {
 			return s.String(), err
 		}
-		if char != r.opts.QuoteChar {
-			s.WriteRune(char)
-		} else {
-			switch r.opts.DoubleQuote {
-			case DoDoubleQuote:
-				char, _, err = r.r.ReadRune()
-				if err != nil {
+		switch {
+		case char == r.opts.EscapeChar && r.opts.DoubleQuote == NoDoubleQuote:
+			if !r.nextIsEscaped() {
+				// Only the quote and escape characters are escaped. Before anything
+				// else the escape character is taken literally, so that "C:\path"
+				// is read as C:\path.
+				if err := r.writeRune(s, char); err != nil {
 					return s.String(), err
 				}
-				if char == r.opts.QuoteChar {
-					s.WriteRune(char)
-				} else {
-					r.r.UnreadRune()
-					return s.String(), nil
-				}
-			case NoDoubleQuote:
-				if s.Len() == 0 {
-					return s.String(), nil
-				}
-				lastRune, size := utf8.DecodeLastRuneInString(s.String())
-				if lastRune == utf8.RuneError && size == 1 {
-					panic("Field contained malformed rune.")
-				}
-				if lastRune == r.opts.EscapeChar {
-					// Replace previous escape character.
-					s.Truncate(s.Len() - utf8.RuneLen(char))
-					s.WriteRune(char)
-				} else {
-					return s.String(), nil
+				continue
+			}
+			char, err = r.readRune()
+			if err == io.EOF && r.strictQuotes() {
+				return s.String(), r.errorAt(r.line, r.column, ErrQuote)
+			}
+			if err != nil {
+				return s.String(), err
+			}
+			if err := r.writeRune(s, char); err != nil {
+				return s.String(), err
+			}
+		case char != r.opts.QuoteChar:
+			if err := r.writeRune(s, char); err != nil {
+				return s.String(), err
+			}
+		case r.opts.DoubleQuote == DoDoubleQuote:
+			char, err = r.readRune()
+			if err == io.EOF {
+				return s.String(), err
+			}
+			if err == nil && char == r.opts.QuoteChar {
+				if err := r.writeRune(s, char); err != nil {
+					return s.String(), err
 				}
-			default:
-				panic("Unrecognized double quote mode.")
+			} else {
+				// Any error is returned when reading the next field.
+				r.unreadRune()
+				return s.String(), nil
 			}
+		case r.opts.DoubleQuote == NoDoubleQuote:
+			return s.String(), nil
+		default:
+			panic("Unrecognized double quote mode.")
 		}
 	}
 
@@ -243,12 +748,19 @@ func (r *Reader) readQuotedField() (string, error) {
 	return s.String(), nil
 }
 
+// nextIsEscaped returns whether the next character can be escaped by the escape
+// character.
+func (r *Reader) nextIsEscaped() bool {
+	isQuote, _ := r.nextIsBytes(r.optimizedQuoteChar)
+	isEscape, _ := r.nextIsBytes([]byte(string(r.opts.EscapeChar)))
+	return isQuote || isEscape
+}
+
 func (r *Reader) readUnquotedField() (string, error) {
-	// TODO: Use bytes.Buffer
 	s := &r.tmpBuf
 	defer r.tmpBuf.Reset() // TODO: Not using defer here is faster.
 	for {
-		char, _, err := r.r.ReadRune()
+		char, err := r.readRune()
 		if err != nil || char == r.opts.Delimiter {
 			// TODO Can a non quoted string be escaped? In that case, it should be
 			// handled here. Should probably have a look at how Python's csv module
@@ -256,11 +768,12 @@ func (r *Reader) readUnquotedField() (string, error) {
 
 			// Putting it back for the outer loop to read separators. This makes more
 			// compatible with readQuotedField().
-			r.r.UnreadRune()
+			r.unreadRune()
 
 			return s.String(), err
-		} else {
-			s.WriteRune(char)
+		}
+		if err := r.writeRune(s, char); err != nil {
+			return s.String(), err
 		}
 		if ok, _ := r.nextIsLineTerminator(); ok {
 			return s.String(), nil
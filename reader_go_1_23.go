@@ -0,0 +1,30 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+//go:build go1.23
+// +build go1.23
+
+package csv
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// All returns an iterator over the remaining records. Iteration stops after
+// EOF, after the first error has been yielded, or when ctx is done, in which
+// case ctx.Err() is yielded.
+func (r *Reader) All(ctx context.Context) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		for {
+			fields, err := r.ReadContext(ctx)
+			if err == io.EOF {
+				return
+			}
+			if !yield(fields, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+//go:build go1.23
+// +build go1.23
+
+package csv
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(strings.NewReader("a,b\nc,d\ne,f\n"))
+	var records [][]string
+	r.All(context.Background())(func(record []string, err error) bool {
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		records = append(records, record)
+		return len(records) < 2
+	})
+	if expected := [][]string{{"a", "b"}, {"c", "d"}}; !reflect.DeepEqual(records, expected) {
+		t.Errorf("Unexpected records: %q Expected: %q", records, expected)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var errs []error
+	r.All(ctx)(func(record []string, err error) bool {
+		errs = append(errs, err)
+		return true
+	})
+	if len(errs) != 1 || errs[0] != context.Canceled {
+		t.Error("Expected a single cancellation error, got:", errs)
+	}
+}
@@ -0,0 +1,43 @@
+package schema_test
+
+import (
+	"fmt"
+	"strings"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/schema"
+)
+
+func ExampleValidator() {
+	s, err := schema.Load(strings.NewReader(`{
+		"fields": [
+			{"name": "id", "type": "integer"},
+			{"name": "email", "format": "email", "constraints": {"required": true}}
+		],
+		"primaryKey": "id"
+	}`))
+	if err != nil {
+		panic(err)
+	}
+
+	input := "id,email\n1,jens@example.com\n1,\nx,jens\n"
+	v, err := schema.NewValidator(csv.NewReader(strings.NewReader(input)), s)
+	if err != nil {
+		panic(err)
+	}
+	v.Header = true
+
+	errs, err := v.Validate()
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+
+	// Output:
+	// row 3, column 2 (email): "": value is required
+	// row 3 (id): primary key is not unique, see row 2
+	// row 4, column 1 (id): "x": value is not of type integer
+	// row 4, column 2 (email): "jens": value is not of type string with format email
+}
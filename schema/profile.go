@@ -0,0 +1,382 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"go/format"
+	"hash/fnv"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JensRantil/go-csv/interfaces"
+)
+
+// A Profiler infers the columns of a table from a sample of its records.
+type Profiler struct {
+	// Maximum number of records to sample, not counting a header. Zero means
+	// all records.
+	SampleSize int
+	// If true, the first record is a header holding the names of the columns.
+	// Otherwise they are named field1, field2 and so on.
+	Header bool
+	// Values that represent a missing value. Defaults to the empty string if
+	// nil.
+	MissingValues []string
+}
+
+// A Profile describes the columns of a table, as inferred from a sample.
+type Profile struct {
+	Columns []ColumnProfile
+	// Number of records sampled, not counting a header.
+	Records int
+	// Missing values used for the inference.
+	MissingValues []string
+}
+
+// A ColumnProfile describes a column of a table.
+type ColumnProfile struct {
+	Name string
+	// The most specific Table Schema type of all values that aren't missing:
+	// integer, number, boolean, date, datetime or string, in that order.
+	// Decimal numbers have the type number. String if all values are missing.
+	Type string
+	// The Table Schema format of dates and datetimes. Empty for the default
+	// format.
+	Format string
+	// Number of missing values, including cells missing from short records.
+	Missing int
+	// Estimated number of distinct values that aren't missing. Exact up to
+	// 1024 distinct values.
+	Distinct int
+	// Minimum and maximum length in characters of values that aren't
+	// missing. Zero if all values are missing.
+	MinLength, MaxLength int
+}
+
+// Nullable returns whether the column has missing values.
+func (c ColumnProfile) Nullable() bool {
+	return c.Missing > 0
+}
+
+// Type and format candidates of a column, from most to least specific.
+var candidates = []Field{
+	{Type: "integer"},
+	{Type: "number"},
+	{Type: "boolean"},
+	{Type: "date"},
+	{Type: "datetime"},
+	{Type: "datetime", Format: "%Y-%m-%dT%H:%M:%S"},
+	{Type: "datetime", Format: "%Y-%m-%d %H:%M:%S"},
+	{Type: "string"},
+}
+
+var candidateCasts = func() []castFunc {
+	casts := make([]castFunc, len(candidates))
+	for i, f := range candidates {
+		var err error
+		if casts[i], err = castFor(f); err != nil {
+			panic(err)
+		}
+	}
+	return casts
+}()
+
+// The statistics of a column while profiling.
+type columnStats struct {
+	ColumnProfile
+	// Whether a value may still be of each candidate type.
+	possible []bool
+	distinct distinctCounter
+	present  int
+}
+
+func (c *columnStats) add(value string) {
+	for i, possible := range c.possible {
+		if possible {
+			if _, err := candidateCasts[i](value); err != nil {
+				c.possible[i] = false
+			}
+		}
+	}
+	c.distinct.add(value)
+	n := utf8.RuneCountInString(value)
+	if c.present == 0 || n < c.MinLength {
+		c.MinLength = n
+	}
+	if n > c.MaxLength {
+		c.MaxLength = n
+	}
+	c.present++
+}
+
+func (c *columnStats) profile() ColumnProfile {
+	p := c.ColumnProfile
+	p.Type = "string"
+	if c.present > 0 {
+		for i, possible := range c.possible {
+			if possible {
+				p.Type, p.Format = candidates[i].Type, candidates[i].Format
+				break
+			}
+		}
+	}
+	p.Distinct = c.distinct.estimate()
+	return p
+}
+
+// Profile reads records from r and infers the columns of the table.
+func (p Profiler) Profile(r interfaces.Reader) (*Profile, error) {
+	missing := make(map[string]bool)
+	result := &Profile{MissingValues: (&Schema{MissingValues: p.MissingValues}).missingValues()}
+	for _, value := range result.MissingValues {
+		missing[value] = true
+	}
+
+	var columns []*columnStats
+	addColumns := func(n int) {
+		for len(columns) < n {
+			c := &columnStats{possible: make([]bool, len(candidates))}
+			c.Name = fmt.Sprintf("field%d", len(columns)+1)
+			c.Missing = result.Records
+			for i := range c.possible {
+				c.possible[i] = true
+			}
+			columns = append(columns, c)
+		}
+	}
+	if p.Header {
+		header, err := r.Read()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		addColumns(len(header))
+		for i, name := range header {
+			columns[i].Name = name
+		}
+	}
+	for p.SampleSize <= 0 || result.Records < p.SampleSize {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		addColumns(len(record))
+		for i, c := range columns {
+			if i >= len(record) || missing[record[i]] {
+				c.Missing++
+			} else {
+				c.add(record[i])
+			}
+		}
+		result.Records++
+	}
+	for _, c := range columns {
+		result.Columns = append(result.Columns, c.profile())
+	}
+	return result, nil
+}
+
+// Schema returns a Table Schema with the inferred types. Columns without
+// missing values are required.
+func (p *Profile) Schema() *Schema {
+	s := &Schema{MissingValues: p.MissingValues}
+	if len(s.MissingValues) == 1 && s.MissingValues[0] == "" {
+		s.MissingValues = nil
+	}
+	for _, c := range p.Columns {
+		f := Field{Name: c.Name, Type: c.Type, Format: c.Format}
+		if !c.Nullable() {
+			f.Constraints = &Constraints{Required: true}
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	return s
+}
+
+// A GoField is a field of a Go struct holding a column.
+type GoField struct {
+	// An exported Go identifier derived from the column name.
+	Name string
+	// The Go type of the field. Pointer types are used for nullable columns,
+	// except for strings.
+	Type string
+	// The name of the column.
+	Column string
+	// The layout of the time package to parse and format time.Time fields.
+	Layout string
+}
+
+// Tag returns the struct tag of the field, such as `csv:"customer id"`, as a Go
+// string literal. It is a raw string literal unless the column name contains a
+// backtick.
+func (f GoField) Tag() string {
+	tag := "csv:" + strconv.Quote(f.Column)
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// GoFields returns a field of a Go struct for each column. Names are unique.
+func (p *Profile) GoFields() []GoField {
+	var fields []GoField
+	used := make(map[string]bool)
+	for _, c := range p.Columns {
+		name := GoName(c.Name)
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", GoName(c.Name), i)
+		}
+		used[name] = true
+
+		var typ, layout string
+		switch c.Type {
+		case "integer":
+			typ = "int64"
+		case "number":
+			typ = "float64"
+		case "boolean":
+			typ = "bool"
+		case "date", "datetime":
+			typ = "time.Time"
+			layout = timeLayout(c.Type, c.Format)
+		default:
+			typ = "string"
+		}
+		if c.Nullable() && typ != "string" {
+			typ = "*" + typ
+		}
+		fields = append(fields, GoField{Name: name, Type: typ, Column: c.Name, Layout: layout})
+	}
+	return fields
+}
+
+// Returns the layout of an inferred date or datetime type and format.
+func timeLayout(typeName, format string) string {
+	if format != "" {
+		layout, _ := strptimeLayout(format)
+		return layout
+	}
+	if typeName == "date" {
+		return "2006-01-02"
+	}
+	return time.RFC3339
+}
+
+// WriteGoStruct writes the definition of a Go struct type with a field for
+// each column, tagged with the column name.
+func (p *Profile) WriteGoStruct(w io.Writer, typeName string) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "type %s struct {\n", typeName)
+	for _, f := range p.GoFields() {
+		fmt.Fprintf(&b, "%s %s %s\n", f.Name, f.Type, f.Tag())
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// Initialisms written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"API": true, "CSV": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "SKU": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// GoName returns an exported Go identifier for a column name, such as
+// "CustomerID" for "customer id".
+func GoName(column string) string {
+	words := strings.FieldsFunc(column, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+	name := b.String()
+	if first, _ := utf8.DecodeRuneInString(name); name == "" || !unicode.IsUpper(first) {
+		name = "Field" + name
+	}
+	return name
+}
+
+// Number of smallest hashes kept by a distinctCounter.
+const distinctSample = 1024
+
+// Estimates the number of distinct values using the k minimum values of
+// their hashes. Exact up to distinctSample values.
+type distinctCounter struct {
+	hashes map[uint64]bool
+	// A max-heap of the hashes, to replace the largest one.
+	largest hashHeap
+}
+
+func (d *distinctCounter) add(value string) {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	hash := mix(h.Sum64())
+	if d.hashes == nil {
+		d.hashes = make(map[uint64]bool)
+	}
+	if d.hashes[hash] {
+		return
+	}
+	if len(d.largest) == distinctSample {
+		if hash > d.largest[0] {
+			return
+		}
+		delete(d.hashes, heap.Pop(&d.largest).(uint64))
+	}
+	d.hashes[hash] = true
+	heap.Push(&d.largest, hash)
+}
+
+func (d *distinctCounter) estimate() int {
+	if len(d.largest) < distinctSample {
+		return len(d.largest)
+	}
+	return int((distinctSample - 1) / (float64(d.largest[0]) / math.MaxUint64))
+}
+
+// Spreads the bits of a hash, since FNV doesn't distribute short strings
+// evenly.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+type hashHeap []uint64
+
+func (h hashHeap) Len() int            { return len(h) }
+func (h hashHeap) Less(i, j int) bool  { return h[i] > h[j] }
+func (h hashHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hashHeap) Push(x interface{}) { *h = append(*h, x.(uint64)) }
+func (h *hashHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,118 @@
+package schema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/schema"
+)
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	input := strings.Join([]string{
+		"id,price,active,day,updated,name,extra",
+		"1,9.99,true,2020-01-01,2020-01-01 10:00:00,Anna,",
+		"2,10,false,2020-01-02,2020-01-01 11:00:00,,x",
+		"3,-1.5,TRUE,2020-01-03,2020-01-01 12:00:00,Anna,",
+		"4,NA,0,NA,NA,Bo,NA",
+	}, "\n")
+	profiler := schema.Profiler{Header: true, MissingValues: []string{"", "NA"}}
+	profile, err := profiler.Profile(csv.NewReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := []schema.ColumnProfile{
+		{Name: "id", Type: "integer", Distinct: 4, MinLength: 1, MaxLength: 1},
+		{Name: "price", Type: "number", Missing: 1, Distinct: 3, MinLength: 2, MaxLength: 4},
+		{Name: "active", Type: "boolean", Distinct: 4, MinLength: 1, MaxLength: 5},
+		{Name: "day", Type: "date", Missing: 1, Distinct: 3, MinLength: 10, MaxLength: 10},
+		{Name: "updated", Type: "datetime", Format: "%Y-%m-%d %H:%M:%S", Missing: 1, Distinct: 3, MinLength: 19, MaxLength: 19},
+		{Name: "name", Type: "string", Missing: 1, Distinct: 2, MinLength: 2, MaxLength: 4},
+		{Name: "extra", Type: "string", Missing: 3, Distinct: 1, MinLength: 1, MaxLength: 1},
+	}
+	if profile.Records != 4 || !reflect.DeepEqual(profile.Columns, expected) {
+		t.Errorf("Unexpected output: %+v Expected: %+v", profile.Columns, expected)
+	}
+
+	b, err := json.Marshal(profile.Schema())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	s, err := schema.Load(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	v, err := schema.NewValidator(csv.NewReader(strings.NewReader(input)), s)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	v.Header = true
+	if errs, err := v.Validate(); errs != nil || err != nil {
+		t.Error("Inferred schema doesn't match the data:", errs, err)
+	}
+}
+
+func TestProfileWithoutHeader(t *testing.T) {
+	t.Parallel()
+
+	input := "1,a\n2,b,c\n3\n"
+	profile, err := schema.Profiler{SampleSize: 2}.Profile(csv.NewReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var names []string
+	for _, c := range profile.Columns {
+		names = append(names, fmt.Sprint(c.Name, ":", c.Type, ":", c.Missing))
+	}
+	expected := []string{"field1:integer:0", "field2:string:0", "field3:string:1"}
+	if profile.Records != 2 || !reflect.DeepEqual(names, expected) {
+		t.Error("Unexpected output:", profile.Records, names, "Expected:", expected)
+	}
+}
+
+func TestProfileDistinct(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&b, "%d\n", i%50000)
+	}
+	profile, err := schema.Profiler{}.Profile(csv.NewReader(strings.NewReader(b.String())))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if distinct := profile.Columns[0].Distinct; distinct < 45000 || distinct > 55000 {
+		t.Error("Unexpected estimate:", distinct, "Expected: 50000")
+	}
+}
+
+func TestWriteGoStruct(t *testing.T) {
+	t.Parallel()
+
+	input := "customer id,Name,name,2nd score,signed up,it`s\n1,a,b,1.5,2020-01-01,x\n2,c,d,,2020-01-02,y\n"
+	profile, err := schema.Profiler{Header: true}.Profile(csv.NewReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var b bytes.Buffer
+	if err := profile.WriteGoStruct(&b, "Customer"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := "type Customer struct {\n" +
+		"\tCustomerID    int64     `csv:\"customer id\"`\n" +
+		"\tName          string    `csv:\"Name\"`\n" +
+		"\tName2         string    `csv:\"name\"`\n" +
+		"\tField2ndScore *float64  `csv:\"2nd score\"`\n" +
+		"\tSignedUp      time.Time `csv:\"signed up\"`\n" +
+		"\tItS           string    \"csv:\\\"it`s\\\"\"\n" +
+		"}\n"
+	if b.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nExpected:\n%s", b.String(), expected)
+	}
+}
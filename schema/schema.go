@@ -0,0 +1,143 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+// Validation of CSV records against Frictionless Data Table Schemas. See
+// https://specs.frictionlessdata.io/table-schema/. This API is currently in
+// alpha. Feel free to discuss it on
+// https://github.com/jensrantil/go-csv/issues.
+package schema
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// A Schema describes the fields of a table, and the keys that identify its
+// rows.
+type Schema struct {
+	Fields []Field `json:"fields"`
+	// Fields that together uniquely identify each row. None of them may be
+	// missing.
+	PrimaryKey Keys `json:"primaryKey,omitempty"`
+	// Other sets of fields that together must be unique.
+	UniqueKeys []Keys `json:"uniqueKeys,omitempty"`
+	// Values that represent a missing value. Defaults to the empty string if
+	// nil.
+	MissingValues []string `json:"missingValues,omitempty"`
+}
+
+// A Field describes a column of a table.
+type Field struct {
+	Name string `json:"name"`
+	// One of string, integer, number, boolean, date, time, datetime, year,
+	// yearmonth, object, array and any. Defaults to string.
+	Type string `json:"type,omitempty"`
+	// Format of the field's values. For string, one of default, email, uri,
+	// uuid and binary. For date, time and datetime, one of default, any or a
+	// strptime pattern such as "%d/%m/%Y".
+	Format      string       `json:"format,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Constraints *Constraints `json:"constraints,omitempty"`
+
+	// Values of boolean fields. Default to "true", "True", "TRUE" and "1", and
+	// "false", "False", "FALSE" and "0".
+	TrueValues  []string `json:"trueValues,omitempty"`
+	FalseValues []string `json:"falseValues,omitempty"`
+
+	// Decimal and grouping characters of number fields. Default to "." and no
+	// grouping.
+	DecimalChar string `json:"decimalChar,omitempty"`
+	GroupChar   string `json:"groupChar,omitempty"`
+	// If false, integer and number fields may have leading and trailing
+	// non-numeric characters, such as in "$10" or "95%". Defaults to true.
+	BareNumber *bool `json:"bareNumber,omitempty"`
+}
+
+// Constraints restrict the values of a Field.
+type Constraints struct {
+	Required bool `json:"required,omitempty"`
+	Unique   bool `json:"unique,omitempty"`
+	// A regular expression that must match the whole value.
+	Pattern string `json:"pattern,omitempty"`
+	// The only values allowed, compared after parsing them as the field's type.
+	Enum      []Literal `json:"enum,omitempty"`
+	Minimum   *Literal  `json:"minimum,omitempty"`
+	Maximum   *Literal  `json:"maximum,omitempty"`
+	MinLength *int      `json:"minLength,omitempty"`
+	MaxLength *int      `json:"maxLength,omitempty"`
+}
+
+// Keys is a list of field names. In JSON, a single name may be given as a
+// string.
+type Keys []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (k *Keys) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*k = Keys{name}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(k))
+}
+
+// A Literal is a value in a schema, such as a minimum. It is the text of a JSON
+// string, number or boolean, and is parsed as the type of the field it
+// applies to.
+type Literal string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *Literal) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if s, ok := v.(string); ok {
+		*l = Literal(s)
+		return nil
+	}
+	*l = Literal(data)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Numbers and booleans are written
+// as such, anything else as a string.
+func (l Literal) MarshalJSON() ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(l), &v); err == nil {
+		switch v.(type) {
+		case float64, bool:
+			return []byte(l), nil
+		}
+	}
+	return json.Marshal(string(l))
+}
+
+// Load reads a Schema in JSON format and checks that it is valid.
+func Load(r io.Reader) (*Schema, error) {
+	var s Schema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if _, err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FieldNames returns the names of the fields.
+func (s *Schema) FieldNames() []string {
+	names := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func (s *Schema) missingValues() []string {
+	if s.MissingValues == nil {
+		return []string{""}
+	}
+	return s.MissingValues
+}
@@ -0,0 +1,70 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JensRantil/go-csv/schema"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	s, err := schema.Load(strings.NewReader(`{
+		"fields": [
+			{"name": "id", "type": "integer"},
+			{"name": "age", "type": "integer", "constraints": {"minimum": 0, "maximum": "150"}},
+			{"name": "grade", "constraints": {"enum": ["A", "B"]}}
+		],
+		"primaryKey": "id",
+		"uniqueKeys": [["age", "grade"]],
+		"missingValues": ["", "NA"]
+	}`))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if names := s.FieldNames(); !reflect.DeepEqual(names, []string{"id", "age", "grade"}) {
+		t.Error("Unexpected output:", names)
+	}
+	if !reflect.DeepEqual(s.PrimaryKey, schema.Keys{"id"}) {
+		t.Error("Unexpected output:", s.PrimaryKey)
+	}
+	if cons := s.Fields[1].Constraints; *cons.Minimum != "0" || *cons.Maximum != "150" {
+		t.Error("Unexpected output:", *cons.Minimum, *cons.Maximum)
+	}
+
+	b, err := json.Marshal(s.Fields[1])
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := `{"name":"age","type":"integer","constraints":{"minimum":0,"maximum":150}}`
+	if string(b) != expected {
+		t.Error("Unexpected output:", string(b), "Expected:", expected)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{
+		`{"fields": [{"name": "a", "type": "duration"}]}`,
+		`{"fields": [{"name": "a"}, {"name": "a"}]}`,
+		`{"fields": [{"type": "integer"}]}`,
+		`{"fields": [{"name": "a", "format": "ipv6"}]}`,
+		`{"fields": [{"name": "a", "type": "date", "format": "%Q"}]}`,
+		`{"fields": [{"name": "a", "constraints": {"pattern": "("}}]}`,
+		`{"fields": [{"name": "a", "type": "integer", "constraints": {"enum": [1, "x"]}}]}`,
+		`{"fields": [{"name": "a", "constraints": {"minimum": "a"}}]}`,
+		`{"fields": [{"name": "a", "type": "integer", "constraints": {"maxLength": 3}}]}`,
+		`{"fields": [{"name": "a"}], "primaryKey": ["b"]}`,
+		`{"fields": [{"name": "a"}], "uniqueKeys": [[]]}`,
+		`{"fields": {}}`,
+	}
+	for _, descriptor := range invalid {
+		if _, err := schema.Load(strings.NewReader(descriptor)); err == nil {
+			t.Error(descriptor, "Expected an error.")
+		}
+	}
+}
@@ -0,0 +1,491 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Parses a value of a field into a Go value: string, int64, float64, bool,
+// time.Time, map[string]interface{} or []interface{}.
+type castFunc func(value string) (interface{}, error)
+
+// A Field prepared for validation.
+type field struct {
+	Field
+	cast      castFunc
+	required  bool
+	unique    bool
+	pattern   *regexp.Regexp
+	enum      map[string]bool
+	min, max  interface{}
+	minLength int
+	maxLength int
+}
+
+// A Schema prepared for validation.
+type compiled struct {
+	fields     []*field
+	missing    map[string]bool
+	primaryKey []int
+	uniqueKeys [][]int
+}
+
+func (s *Schema) compile() (*compiled, error) {
+	c := &compiled{missing: make(map[string]bool)}
+	for _, value := range s.missingValues() {
+		c.missing[value] = true
+	}
+	indices := make(map[string]int, len(s.Fields))
+	for i, f := range s.Fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("field %d has no name.", i+1)
+		}
+		if _, ok := indices[f.Name]; ok {
+			return nil, fmt.Errorf("duplicate field %q.", f.Name)
+		}
+		indices[f.Name] = i
+		cf, err := compileField(f)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", f.Name, err)
+		}
+		c.fields = append(c.fields, cf)
+	}
+	keyIndices := func(keys Keys) ([]int, error) {
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("empty key.")
+		}
+		var result []int
+		for _, name := range keys {
+			i, ok := indices[name]
+			if !ok {
+				return nil, fmt.Errorf("key refers to unknown field %q.", name)
+			}
+			result = append(result, i)
+		}
+		return result, nil
+	}
+	if s.PrimaryKey != nil {
+		var err error
+		if c.primaryKey, err = keyIndices(s.PrimaryKey); err != nil {
+			return nil, err
+		}
+		for _, i := range c.primaryKey {
+			c.fields[i].required = true
+		}
+	}
+	for _, keys := range s.UniqueKeys {
+		uniqueKey, err := keyIndices(keys)
+		if err != nil {
+			return nil, err
+		}
+		c.uniqueKeys = append(c.uniqueKeys, uniqueKey)
+	}
+	return c, nil
+}
+
+func compileField(f Field) (*field, error) {
+	cf := &field{Field: f, minLength: -1, maxLength: -1}
+	var err error
+	if cf.cast, err = castFor(f); err != nil {
+		return nil, err
+	}
+	if f.Constraints == nil {
+		return cf, nil
+	}
+	cons := f.Constraints
+	cf.required = cons.Required
+	cf.unique = cons.Unique
+	if cons.Pattern != "" {
+		if cf.pattern, err = regexp.Compile("^(?:" + cons.Pattern + ")$"); err != nil {
+			return nil, fmt.Errorf("pattern: %v", err)
+		}
+	}
+	if cons.Enum != nil {
+		cf.enum = make(map[string]bool, len(cons.Enum))
+		for _, literal := range cons.Enum {
+			v, err := cf.cast(string(literal))
+			if err != nil {
+				return nil, fmt.Errorf("enum value %q is not of type %s.", literal, f.typeName())
+			}
+			cf.enum[valueKey(v)] = true
+		}
+	}
+	bound := func(name string, literal *Literal) (interface{}, error) {
+		if literal == nil {
+			return nil, nil
+		}
+		if !ordered(f.typeName()) {
+			return nil, fmt.Errorf("%s can't be used with type %s.", name, f.typeName())
+		}
+		v, err := cf.cast(string(*literal))
+		if err != nil {
+			return nil, fmt.Errorf("%s %q is not of type %s.", name, *literal, f.typeName())
+		}
+		return v, nil
+	}
+	if cf.min, err = bound("minimum", cons.Minimum); err != nil {
+		return nil, err
+	}
+	if cf.max, err = bound("maximum", cons.Maximum); err != nil {
+		return nil, err
+	}
+	if cons.MinLength != nil || cons.MaxLength != nil {
+		switch f.typeName() {
+		case "string", "array", "object":
+		default:
+			return nil, fmt.Errorf("length constraints can't be used with type %s.", f.typeName())
+		}
+		if cons.MinLength != nil {
+			cf.minLength = *cons.MinLength
+		}
+		if cons.MaxLength != nil {
+			cf.maxLength = *cons.MaxLength
+		}
+	}
+	return cf, nil
+}
+
+func (f Field) typeName() string {
+	if f.Type == "" {
+		return "string"
+	}
+	return f.Type
+}
+
+func (f Field) bareNumber() bool {
+	return f.BareNumber == nil || *f.BareNumber
+}
+
+// Returns whether minimum and maximum can be used with a type.
+func ordered(typeName string) bool {
+	switch typeName {
+	case "integer", "number", "date", "time", "datetime", "year", "yearmonth":
+		return true
+	}
+	return false
+}
+
+// Returns the length of a value for the length constraints.
+func length(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		return utf8.RuneCountInString(v)
+	case []interface{}:
+		return len(v)
+	case map[string]interface{}:
+		return len(v)
+	}
+	return 0
+}
+
+// Compares two values of the same ordered type.
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case time.Time:
+		b := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+	}
+	return 0
+}
+
+// Returns a string that is equal for equal values, to look up values in enums
+// and to check uniqueness.
+func valueKey(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func castFor(f Field) (castFunc, error) {
+	switch f.typeName() {
+	case "string":
+		return castString(f.Format)
+	case "any":
+		return func(value string) (interface{}, error) { return value, nil }, nil
+	case "integer":
+		bare := f.bareNumber()
+		return func(value string) (interface{}, error) {
+			if !bare {
+				value = trimNumber(value)
+			}
+			return strconv.ParseInt(value, 10, 64)
+		}, nil
+	case "number":
+		return castNumber(f)
+	case "boolean":
+		return castBoolean(f), nil
+	case "date":
+		return castTime(f.Format, "2006-01-02")
+	case "time":
+		return castTime(f.Format, "15:04:05")
+	case "datetime":
+		return castTime(f.Format, time.RFC3339)
+	case "year":
+		return func(value string) (interface{}, error) {
+			if len(strings.TrimPrefix(value, "-")) != 4 {
+				return nil, fmt.Errorf("invalid year %q", value)
+			}
+			return strconv.ParseInt(value, 10, 64)
+		}, nil
+	case "yearmonth":
+		return castTime("", "2006-01")
+	case "object":
+		return func(value string) (interface{}, error) {
+			var v map[string]interface{}
+			err := json.Unmarshal([]byte(value), &v)
+			if err == nil && v == nil {
+				err = fmt.Errorf("invalid object %q", value)
+			}
+			return v, err
+		}, nil
+	case "array":
+		return func(value string) (interface{}, error) {
+			var v []interface{}
+			err := json.Unmarshal([]byte(value), &v)
+			if err == nil && v == nil {
+				err = fmt.Errorf("invalid array %q", value)
+			}
+			return v, err
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q.", f.Type)
+}
+
+func castString(format string) (castFunc, error) {
+	switch format {
+	case "", "default":
+		return func(value string) (interface{}, error) { return value, nil }, nil
+	case "email":
+		return func(value string) (interface{}, error) {
+			addr, err := mail.ParseAddress(value)
+			if err != nil || addr.Name != "" || addr.Address != value {
+				return nil, fmt.Errorf("invalid email address %q", value)
+			}
+			return value, nil
+		}, nil
+	case "uri":
+		return func(value string) (interface{}, error) {
+			u, err := url.Parse(value)
+			if err != nil || u.Scheme == "" {
+				return nil, fmt.Errorf("invalid URI %q", value)
+			}
+			return value, nil
+		}, nil
+	case "uuid":
+		return func(value string) (interface{}, error) {
+			if !uuidPattern.MatchString(value) {
+				return nil, fmt.Errorf("invalid UUID %q", value)
+			}
+			return value, nil
+		}, nil
+	case "binary":
+		return func(value string) (interface{}, error) {
+			if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported string format %q.", format)
+}
+
+// Removes leading and trailing characters that can't be part of a number.
+func trimNumber(value string) string {
+	isNumeric := func(r rune) bool {
+		return r >= '0' && r <= '9' || r == '-' || r == '+' || r == '.'
+	}
+	start := strings.IndexFunc(value, isNumeric)
+	end := strings.LastIndexFunc(value, func(r rune) bool { return r >= '0' && r <= '9' })
+	if start < 0 || end < start {
+		return value
+	}
+	return value[start : end+1]
+}
+
+func castNumber(f Field) (castFunc, error) {
+	decimalChar := f.DecimalChar
+	if decimalChar == "" {
+		decimalChar = "."
+	}
+	if decimalChar == f.GroupChar {
+		return nil, fmt.Errorf("decimalChar and groupChar must differ.")
+	}
+	bare := f.bareNumber()
+	return func(value string) (interface{}, error) {
+		switch value {
+		case "NaN":
+			return math.NaN(), nil
+		case "INF":
+			return math.Inf(1), nil
+		case "-INF":
+			return math.Inf(-1), nil
+		}
+		if !bare {
+			value = trimNumber(value)
+		}
+		if f.GroupChar != "" {
+			value = strings.ReplaceAll(value, f.GroupChar, "")
+		}
+		if decimalChar != "." {
+			if strings.Contains(value, ".") {
+				return nil, fmt.Errorf("invalid number %q", value)
+			}
+			value = strings.ReplaceAll(value, decimalChar, ".")
+		}
+		// Don't accept the hexadecimal and special values of strconv.
+		for _, r := range value {
+			if !(r >= '0' && r <= '9' || strings.ContainsRune(".eE+-", r)) {
+				return nil, fmt.Errorf("invalid number %q", value)
+			}
+		}
+		return strconv.ParseFloat(value, 64)
+	}, nil
+}
+
+func castBoolean(f Field) castFunc {
+	values := make(map[string]bool)
+	trueValues, falseValues := f.TrueValues, f.FalseValues
+	if trueValues == nil {
+		trueValues = []string{"true", "True", "TRUE", "1"}
+	}
+	if falseValues == nil {
+		falseValues = []string{"false", "False", "FALSE", "0"}
+	}
+	for _, value := range trueValues {
+		values[value] = true
+	}
+	for _, value := range falseValues {
+		values[value] = false
+	}
+	return func(value string) (interface{}, error) {
+		b, ok := values[value]
+		if !ok {
+			return nil, fmt.Errorf("invalid boolean %q", value)
+		}
+		return b, nil
+	}
+}
+
+// Layouts tried by the "any" format of dates, times and datetimes.
+var anyLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"15:04:05",
+	"15:04",
+	"02 Jan 2006",
+	"Jan 2, 2006",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+func castTime(format, defaultLayout string) (castFunc, error) {
+	layouts := []string{defaultLayout}
+	switch format {
+	case "", "default":
+	case "any":
+		layouts = anyLayouts
+	default:
+		layout, err := strptimeLayout(format)
+		if err != nil {
+			return nil, err
+		}
+		layouts = []string{layout}
+	}
+	return func(value string) (interface{}, error) {
+		var err error
+		for _, layout := range layouts {
+			var t time.Time
+			if t, err = time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+		return nil, err
+	}, nil
+}
+
+var strptimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'b': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "000000",
+	'p': "PM",
+	'z': "-0700",
+	'Z': "MST",
+	'%': "%",
+}
+
+// Converts a strptime pattern such as "%Y-%m-%d" to a layout of the time
+// package.
+func strptimeLayout(format string) (string, error) {
+	var layout strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			layout.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i == len(format) {
+			return "", fmt.Errorf("format %q ends with %%.", format)
+		}
+		directive, ok := strptimeDirectives[format[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c in format %q.", format[i], format)
+		}
+		layout.WriteString(directive)
+	}
+	return layout.String(), nil
+}
@@ -0,0 +1,319 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JensRantil/go-csv/interfaces"
+)
+
+// Errors that describe why a value doesn't conform to a Schema. The Err of an
+// Error is one of these, possibly wrapped with more details. Use errors.Is to
+// check for them.
+var (
+	ErrHeader      = errors.New("header doesn't match field name")
+	ErrExtraCell   = errors.New("cell has no field")
+	ErrMissingCell = errors.New("cell is missing")
+	ErrRequired    = errors.New("value is required")
+	ErrType        = errors.New("value is not of type")
+	ErrPattern     = errors.New("value doesn't match pattern")
+	ErrEnum        = errors.New("value is not one of the allowed values")
+	ErrMinimum     = errors.New("value is less than minimum")
+	ErrMaximum     = errors.New("value is greater than maximum")
+	ErrMinLength   = errors.New("value is shorter than minimum length")
+	ErrMaxLength   = errors.New("value is longer than maximum length")
+	ErrUnique      = errors.New("value is not unique")
+	ErrPrimaryKey  = errors.New("primary key is not unique")
+)
+
+// An Error describes a record, or a value of a record, that doesn't conform to
+// a Schema.
+type Error struct {
+	// Number of the record, starting at 1. A header counts as a record.
+	Row int
+	// Number of the column, starting at 1. Zero if the error concerns several
+	// columns, such as a non-unique primary key.
+	Column int
+	// Name of the field, or names of the fields separated by commas if Column
+	// is zero. Empty for an extra cell.
+	Field string
+	// The value of the cell. Empty if Column is zero.
+	Value string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "row %d", e.Row)
+	if e.Column > 0 {
+		fmt.Fprintf(&b, ", column %d", e.Column)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, " (%s)", e.Field)
+	}
+	if e.Column > 0 {
+		fmt.Fprintf(&b, ": %q", e.Value)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors are all the validation errors of one or more records.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the errors, so that errors.Is and errors.As can find them
+// with Go 1.20 and later.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// A Validator reads records and checks them against a Schema.
+//
+// To check uniqueness, a Validator remembers the values of unique fields and
+// keys of all records read so far.
+type Validator struct {
+	// If true, the first record is a header. Its names must equal the names
+	// of the fields of the schema, in the same order. The header isn't
+	// returned by Read unless it is invalid.
+	Header bool
+
+	r      interfaces.Reader
+	schema *compiled
+	row    int
+	// Columns of the primary key, if any, followed by those of the unique
+	// keys.
+	keyColumns [][]int
+	// Row of the first occurrence of every value of unique fields and of
+	// every key.
+	uniqueValues map[int]map[string]int
+	keys         []map[string]int
+}
+
+// NewValidator returns a Validator that reads records from r and checks them
+// against s. It returns an error if s is invalid.
+func NewValidator(r interfaces.Reader, s *Schema) (*Validator, error) {
+	c, err := s.compile()
+	if err != nil {
+		return nil, err
+	}
+	v := &Validator{
+		r:            r,
+		schema:       c,
+		uniqueValues: make(map[int]map[string]int),
+	}
+	for i, f := range c.fields {
+		if f.unique {
+			v.uniqueValues[i] = make(map[string]int)
+		}
+	}
+	if c.primaryKey != nil {
+		v.keyColumns = append(v.keyColumns, c.primaryKey)
+	}
+	v.keyColumns = append(v.keyColumns, c.uniqueKeys...)
+	for range v.keyColumns {
+		v.keys = append(v.keys, make(map[string]int))
+	}
+	return v, nil
+}
+
+// Read reads a record and checks it against the schema. If the record doesn't
+// conform, it is returned together with an Errors describing why. Other
+// errors, such as io.EOF, are returned as is.
+func (v *Validator) Read() ([]string, error) {
+	record, err := v.r.Read()
+	if err != nil {
+		return record, err
+	}
+	v.row++
+	if v.row == 1 && v.Header {
+		if errs := v.checkHeader(record); errs != nil {
+			return record, errs
+		}
+		return v.Read()
+	}
+	if errs := v.check(record); errs != nil {
+		return record, errs
+	}
+	return record, nil
+}
+
+// ReadAll reads all the remaining records. It stops at the first error, which
+// may be an Errors.
+func (v *Validator) ReadAll() ([][]string, error) {
+	var records [][]string
+	for {
+		record, err := v.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// Validate reads all the remaining records and returns all their validation
+// errors, or nil if all of them conform. Other errors, such as parse errors,
+// stop the validation and are returned as err.
+func (v *Validator) Validate() (Errors, error) {
+	var all Errors
+	for {
+		_, err := v.Read()
+		if err == io.EOF {
+			return all, nil
+		}
+		var errs Errors
+		if !errors.As(err, &errs) && err != nil {
+			return all, err
+		}
+		all = append(all, errs...)
+	}
+}
+
+func (v *Validator) checkHeader(record []string) Errors {
+	var errs Errors
+	fields := v.schema.fields
+	for i := 0; i < len(record) || i < len(fields); i++ {
+		var name, value string
+		if i < len(fields) {
+			name = fields[i].Name
+		}
+		if i < len(record) {
+			value = record[i]
+		}
+		if i >= len(fields) || i >= len(record) || name != value {
+			errs = append(errs, &Error{Row: v.row, Column: i + 1, Field: name, Value: value, Err: ErrHeader})
+		}
+	}
+	return errs
+}
+
+func (v *Validator) check(record []string) Errors {
+	var errs Errors
+	add := func(column int, f *field, value string, err error) {
+		e := &Error{Row: v.row, Column: column, Value: value, Err: err}
+		if f != nil {
+			e.Field = f.Name
+		}
+		errs = append(errs, e)
+	}
+
+	fields := v.schema.fields
+	values := make([]interface{}, len(fields))
+	for i, value := range record {
+		if i >= len(fields) {
+			add(i+1, nil, value, ErrExtraCell)
+			continue
+		}
+		f := fields[i]
+		if v.schema.missing[value] {
+			if f.required {
+				add(i+1, f, value, ErrRequired)
+			}
+			continue
+		}
+		typed, err := v.checkValue(f, value)
+		if err != nil {
+			add(i+1, f, value, err)
+			continue
+		}
+		values[i] = typed
+		if seen, ok := v.uniqueValues[i]; ok {
+			key := valueKey(typed)
+			if row, ok := seen[key]; ok {
+				add(i+1, f, value, fmt.Errorf("%w, see row %d", ErrUnique, row))
+			} else {
+				seen[key] = v.row
+			}
+		}
+	}
+	for i := len(record); i < len(fields); i++ {
+		add(i+1, fields[i], "", ErrMissingCell)
+	}
+
+	for k, columns := range v.keyColumns {
+		key, ok := compositeKey(values, columns)
+		if !ok {
+			continue
+		}
+		if row, ok := v.keys[k][key]; ok {
+			err := ErrUnique
+			if k == 0 && v.schema.primaryKey != nil {
+				err = ErrPrimaryKey
+			}
+			var names []string
+			for _, i := range columns {
+				names = append(names, fields[i].Name)
+			}
+			errs = append(errs, &Error{Row: v.row, Field: strings.Join(names, ","), Err: fmt.Errorf("%w, see row %d", err, row)})
+		} else {
+			v.keys[k][key] = v.row
+		}
+	}
+	return errs
+}
+
+// Checks a value that isn't missing against the constraints of a field, and
+// returns the parsed value.
+func (v *Validator) checkValue(f *field, value string) (interface{}, error) {
+	typed, err := f.cast(value)
+	if err != nil {
+		if f.Format != "" && f.Format != "default" {
+			return nil, fmt.Errorf("%w %s with format %s", ErrType, f.typeName(), f.Format)
+		}
+		return nil, fmt.Errorf("%w %s", ErrType, f.typeName())
+	}
+	cons := f.Constraints
+	switch {
+	case f.pattern != nil && !f.pattern.MatchString(value):
+		return nil, fmt.Errorf("%w %s", ErrPattern, cons.Pattern)
+	case f.enum != nil && !f.enum[valueKey(typed)]:
+		return nil, ErrEnum
+	case f.min != nil && compare(typed, f.min) < 0:
+		return nil, fmt.Errorf("%w %s", ErrMinimum, *cons.Minimum)
+	case f.max != nil && compare(typed, f.max) > 0:
+		return nil, fmt.Errorf("%w %s", ErrMaximum, *cons.Maximum)
+	case f.minLength >= 0 && length(typed) < f.minLength:
+		return nil, fmt.Errorf("%w %d", ErrMinLength, f.minLength)
+	case f.maxLength >= 0 && length(typed) > f.maxLength:
+		return nil, fmt.Errorf("%w %d", ErrMaxLength, f.maxLength)
+	}
+	return typed, nil
+}
+
+// Returns a string identifying the values of a key. Keys with missing or
+// invalid values aren't checked for uniqueness.
+func compositeKey(values []interface{}, columns []int) (string, bool) {
+	parts := make([]string, len(columns))
+	for j, i := range columns {
+		if values[i] == nil {
+			return "", false
+		}
+		parts[j] = valueKey(values[i])
+	}
+	b, _ := json.Marshal(parts)
+	return string(b), true
+}
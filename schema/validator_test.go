@@ -0,0 +1,229 @@
+package schema_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	csv "github.com/JensRantil/go-csv"
+	"github.com/JensRantil/go-csv/schema"
+)
+
+func mustLoad(t *testing.T, descriptor string) *schema.Schema {
+	s, err := schema.Load(strings.NewReader(descriptor))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	return s
+}
+
+func newValidator(t *testing.T, descriptor, input string) *schema.Validator {
+	v, err := schema.NewValidator(csv.NewReader(strings.NewReader(input)), mustLoad(t, descriptor))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	return v
+}
+
+// A validation error without the wrapped details.
+type position struct {
+	Row, Column int
+	Field       string
+	Err         error
+}
+
+func positions(errs schema.Errors) []position {
+	sentinels := []error{
+		schema.ErrHeader, schema.ErrExtraCell, schema.ErrMissingCell, schema.ErrRequired,
+		schema.ErrType, schema.ErrPattern, schema.ErrEnum, schema.ErrMinimum, schema.ErrMaximum,
+		schema.ErrMinLength, schema.ErrMaxLength, schema.ErrUnique, schema.ErrPrimaryKey,
+	}
+	var result []position
+	for _, e := range errs {
+		p := position{e.Row, e.Column, e.Field, e.Err}
+		for _, sentinel := range sentinels {
+			if errors.Is(e, sentinel) {
+				p.Err = sentinel
+			}
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	descriptor := `{
+		"fields": [
+			{"name": "id", "type": "integer"},
+			{"name": "email", "format": "email", "constraints": {"unique": true}},
+			{"name": "age", "type": "integer", "constraints": {"minimum": 18, "maximum": 65}},
+			{"name": "code", "constraints": {"required": true, "pattern": "[A-Z]{2}", "enum": ["SE", "NO"]}},
+			{"name": "joined", "type": "date", "format": "%d/%m/%Y", "constraints": {"minimum": "01/01/2000"}},
+			{"name": "name", "constraints": {"minLength": 2, "maxLength": 5}}
+		],
+		"primaryKey": "id",
+		"missingValues": ["", "-"]
+	}`
+	input := strings.Join([]string{
+		"id,email,age,code,joined,name",
+		"1,a@example.com,30,SE,24/12/2010,Anna",
+		"2,b@example.com,17,se,31/02/2010,A",
+		"-,a@example.com,66,DK,01/01/1999,Annabel",
+		"2,-,x,,-,Bo,extra",
+		"3,c",
+	}, "\n")
+	v := newValidator(t, descriptor, input)
+	v.Header = true
+	errs, err := v.Validate()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := []position{
+		{3, 3, "age", schema.ErrMinimum},
+		{3, 4, "code", schema.ErrPattern},
+		{3, 5, "joined", schema.ErrType},
+		{3, 6, "name", schema.ErrMinLength},
+		{4, 1, "id", schema.ErrRequired},
+		{4, 2, "email", schema.ErrUnique},
+		{4, 3, "age", schema.ErrMaximum},
+		{4, 4, "code", schema.ErrEnum},
+		{4, 5, "joined", schema.ErrMinimum},
+		{4, 6, "name", schema.ErrMaxLength},
+		{5, 3, "age", schema.ErrType},
+		{5, 4, "code", schema.ErrRequired},
+		{5, 7, "", schema.ErrExtraCell},
+		{5, 0, "id", schema.ErrPrimaryKey},
+		{6, 2, "email", schema.ErrType},
+		{6, 3, "age", schema.ErrMissingCell},
+		{6, 4, "code", schema.ErrMissingCell},
+		{6, 5, "joined", schema.ErrMissingCell},
+		{6, 6, "name", schema.ErrMissingCell},
+	}
+	if got := positions(errs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected output:\n%v\nExpected:\n%v", got, expected)
+	}
+
+	message := `row 4, column 2 (email): "a@example.com": value is not unique, see row 2`
+	if errs[5].Error() != message {
+		t.Error("Unexpected output:", errs[5].Error(), "Expected:", message)
+	}
+	message = `row 5 (id): primary key is not unique, see row 3`
+	if errs[13].Error() != message {
+		t.Error("Unexpected output:", errs[13].Error(), "Expected:", message)
+	}
+}
+
+func TestValidateTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		field   string
+		valid   []string
+		invalid []string
+	}{
+		{`{"name": "a"}`, []string{"x", "1"}, nil},
+		{`{"name": "a", "format": "uri"}`, []string{"https://example.com/"}, []string{"example.com"}},
+		{`{"name": "a", "format": "uuid"}`, []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567"}},
+		{`{"name": "a", "format": "binary"}`, []string{"aGk="}, []string{"a"}},
+		{`{"name": "a", "type": "integer"}`, []string{"1", "-20", "+3"}, []string{"1.0", "1e3", "x", "0x10"}},
+		{`{"name": "a", "type": "integer", "bareNumber": false}`, []string{"$10", "95%"}, []string{"$"}},
+		{`{"name": "a", "type": "number"}`, []string{"1", "1.5", "-2e3", "NaN", "-INF"}, []string{"1,5", "0x1p3", "Inf"}},
+		{`{"name": "a", "type": "number", "decimalChar": ",", "groupChar": " "}`, []string{"1 000,5"}, []string{"1.5"}},
+		{`{"name": "a", "type": "boolean"}`, []string{"true", "FALSE", "1"}, []string{"yes"}},
+		{`{"name": "a", "type": "boolean", "trueValues": ["yes"], "falseValues": ["no"]}`, []string{"yes", "no"}, []string{"true"}},
+		{`{"name": "a", "type": "date"}`, []string{"2020-02-29"}, []string{"2021-02-29", "29/02/2020"}},
+		{`{"name": "a", "type": "date", "format": "any"}`, []string{"2020-02-29", "29 Feb 2020"}, []string{"yesterday"}},
+		{`{"name": "a", "type": "time"}`, []string{"23:59:59"}, []string{"24:00:00"}},
+		{`{"name": "a", "type": "datetime"}`, []string{"2020-02-29T12:00:00Z", "2020-02-29T12:00:00+01:00"}, []string{"2020-02-29 12:00:00"}},
+		{`{"name": "a", "type": "datetime", "format": "%Y-%m-%d %H:%M:%S.%f"}`, []string{"2020-02-29 12:00:00.123456"}, []string{"2020-02-29 12:00:00"}},
+		{`{"name": "a", "type": "year"}`, []string{"2020"}, []string{"20", "20200"}},
+		{`{"name": "a", "type": "yearmonth"}`, []string{"2020-02"}, []string{"2020-13"}},
+		{`{"name": "a", "type": "object"}`, []string{`{"b": 1}`}, []string{`[1]`, `null`}},
+		{`{"name": "a", "type": "array", "constraints": {"maxLength": 2}}`, []string{`[1, 2]`}, []string{`{}`, `[1, 2, 3]`}},
+		{`{"name": "a", "type": "any"}`, []string{"anything"}, nil},
+	}
+	for _, test := range tests {
+		descriptor := `{"fields": [` + test.field + `], "missingValues": []}`
+		for _, value := range test.valid {
+			v := newValidator(t, descriptor, csvLine(value))
+			if _, err := v.Read(); err != nil {
+				t.Error(test.field, value, "Unexpected error:", err)
+			}
+		}
+		for _, value := range test.invalid {
+			v := newValidator(t, descriptor, csvLine(value))
+			if _, err := v.Read(); err == nil || err == io.EOF {
+				t.Error(test.field, value, "Expected an error.")
+			}
+		}
+	}
+}
+
+// Returns a line with a single field.
+func csvLine(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"` + "\n"
+}
+
+func TestValidateUniqueKeys(t *testing.T) {
+	t.Parallel()
+
+	descriptor := `{
+		"fields": [{"name": "a", "type": "integer"}, {"name": "b"}],
+		"uniqueKeys": [["a", "b"]]
+	}`
+	v := newValidator(t, descriptor, "1,x\n01,x\n1,y\n,x\n,x\n")
+	errs, err := v.Validate()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := []position{{2, 0, "a,b", schema.ErrUnique}}
+	if got := positions(errs); !reflect.DeepEqual(got, expected) {
+		t.Error("Unexpected output:", got, "Expected:", expected)
+	}
+}
+
+func TestValidatorRead(t *testing.T) {
+	t.Parallel()
+
+	descriptor := `{"fields": [{"name": "a", "type": "integer"}]}`
+	v := newValidator(t, descriptor, "b\n1\nx\n")
+	v.Header = true
+	record, err := v.Read()
+	var errs schema.Errors
+	if !errors.As(err, &errs) || !reflect.DeepEqual(record, []string{"b"}) {
+		t.Fatal("Unexpected output:", record, err)
+	}
+	if expected := `row 1, column 1 (a): "b": header doesn't match field name`; errs.Error() != expected {
+		t.Error("Unexpected output:", errs.Error(), "Expected:", expected)
+	}
+
+	records, err := v.ReadAll()
+	if !reflect.DeepEqual(records, [][]string{{"1"}}) || !errors.Is(err, schema.ErrType) {
+		t.Error("Unexpected output:", records, err)
+	}
+	if _, err := v.Read(); err != io.EOF {
+		t.Error("Unexpected error:", err)
+	}
+
+	v = newValidator(t, descriptor, "a\n1\n")
+	v.Header = true
+	if records, err := v.ReadAll(); err != nil || !reflect.DeepEqual(records, [][]string{{"1"}}) {
+		t.Error("Unexpected output:", records, err)
+	}
+
+	// Parse errors stop the validation.
+	r := csv.NewReader(strings.NewReader("x\n\"1\n"))
+	r.StrictQuotes = true
+	v, err = schema.NewValidator(r, mustLoad(t, descriptor))
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	errs, err = v.Validate()
+	if len(errs) != 1 || !errors.Is(err, csv.ErrQuote) {
+		t.Error("Unexpected output:", errs, err)
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dialects registered by default. See RegisterDialect. Like Python's, they
+// have no comment character.
+var (
+	// Python's excel dialect. What Excel writes when saving as CSV.
+	ExcelDialect = Dialect{
+		Delimiter:      ',',
+		QuoteChar:      '"',
+		DoubleQuote:    DoDoubleQuote,
+		Quoting:        QuoteMinimal,
+		LineTerminator: "\r\n",
+		Comment:        NoComment,
+	}
+	// Python's excel-tab dialect. Like ExcelDialect, but tab delimited.
+	ExcelTabDialect = Dialect{
+		Delimiter:      '\t',
+		QuoteChar:      '"',
+		DoubleQuote:    DoDoubleQuote,
+		Quoting:        QuoteMinimal,
+		LineTerminator: "\r\n",
+		Comment:        NoComment,
+	}
+	// Python's unix dialect. Every field is quoted.
+	UnixDialect = Dialect{
+		Delimiter:      ',',
+		QuoteChar:      '"',
+		DoubleQuote:    DoDoubleQuote,
+		Quoting:        QuoteAll,
+		LineTerminator: "\n",
+		Comment:        NoComment,
+	}
+	// The format described by RFC 4180.
+	RFC4180Dialect = ExcelDialect
+)
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		"excel":     ExcelDialect,
+		"excel-tab": ExcelTabDialect,
+		"unix":      UnixDialect,
+		"rfc4180":   RFC4180Dialect,
+	}
+)
+
+// RegisterDialect registers a dialect under a name, so that it can be used in
+// dialect specs. An already registered dialect with the same name is
+// replaced. Names must not contain '=' or ';'.
+func RegisterDialect(name string, d Dialect) {
+	if strings.ContainsAny(name, "=;") {
+		panic("csv: invalid dialect name " + strconv.Quote(name))
+	}
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[name] = d
+}
+
+// LookupDialect returns the dialect registered under a name.
+func LookupDialect(name string) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[name]
+	return d, ok
+}
+
+// ParseDialect parses a dialect spec, a compact string describing a Dialect
+// such as
+//
+//	delim=\t;quote=";escape=\;quoting=minimal;lt=\r\n
+//
+// It is a semicolon separated list of settings. Each setting is a key, an equal
+// sign and a value:
+//
+//	delim             Delimiter
+//	quote             QuoteChar
+//	escape            EscapeChar
+//	doublequote       DoubleQuote, true or false
+//	quoting           Quoting, see QuoteMode.UnmarshalText
+//	decimal           NumberFormat.Decimal
+//	grouping          NumberFormat.Grouping, or nothing for no grouping
+//	lt                LineTerminator
+//	comment           Comment, or nothing for NoComment
+//	skipinitialspace  SkipInitialSpace, true or false
+//	encoding          Encoding, see Encoding.UnmarshalText
+//	oninvalid         OnInvalid, see InvalidPolicy.UnmarshalText
+//	formulaescape     FormulaEscape, see FormulaEscapeMode.UnmarshalText
+//
+// Values may contain the escape sequences \\, \t, \r, \n, \xHH, \uHHHH and
+// \UHHHHHHHH. Any other backslash is taken literally. A semicolon in a value
+// must be written as \x3b.
+//
+// The first item may instead be the name of a registered dialect, such as
+// "excel". The settings that follow override it, as in "excel;delim=\t".
+//
+// Settings that are not given keep their zero value, so the defaults apply.
+func ParseDialect(spec string) (Dialect, error) {
+	var d Dialect
+	for i, item := range strings.Split(spec, ";") {
+		key, value, isSetting := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !isSetting {
+			if key == "" {
+				continue
+			}
+			if i > 0 {
+				return Dialect{}, fmt.Errorf("csv: dialect setting %s has no value", key)
+			}
+			base, ok := LookupDialect(key)
+			if !ok {
+				return Dialect{}, fmt.Errorf("csv: unknown dialect %q", key)
+			}
+			d = base
+			continue
+		}
+		value, err := unescapeSpec(value)
+		if err != nil {
+			return Dialect{}, fmt.Errorf("csv: dialect setting %s: %v", key, err)
+		}
+		if err := d.set(key, value); err != nil {
+			return Dialect{}, fmt.Errorf("csv: dialect setting %s: %v", key, err)
+		}
+	}
+	return d, nil
+}
+
+func (d *Dialect) set(key, value string) error {
+	var err error
+	switch key {
+	case "delim":
+		d.Delimiter, err = specRune(value)
+	case "quote":
+		d.QuoteChar, err = specRune(value)
+	case "escape":
+		d.EscapeChar, err = specRune(value)
+	case "comment":
+		if value == "" {
+			d.Comment = NoComment
+			break
+		}
+		d.Comment, err = specRune(value)
+	case "doublequote":
+		var doubleQuote bool
+		if doubleQuote, err = strconv.ParseBool(value); err == nil {
+			d.DoubleQuote = NoDoubleQuote
+			if doubleQuote {
+				d.DoubleQuote = DoDoubleQuote
+			}
+		}
+	case "quoting":
+		err = d.Quoting.UnmarshalText([]byte(value))
+	case "decimal":
+		d.NumberFormat.Decimal, err = specRune(value)
+	case "grouping":
+		d.NumberFormat.Grouping = 0
+		if value != "" {
+			d.NumberFormat.Grouping, err = specRune(value)
+		}
+	case "skipinitialspace":
+		d.SkipInitialSpace, err = strconv.ParseBool(value)
+	case "lt":
+		d.LineTerminator = value
+	case "encoding":
+		err = d.Encoding.UnmarshalText([]byte(value))
+	case "oninvalid":
+		err = d.OnInvalid.UnmarshalText([]byte(value))
+	case "formulaescape":
+		err = d.FormulaEscape.UnmarshalText([]byte(value))
+	default:
+		return errors.New("unknown setting")
+	}
+	return err
+}
+
+// String returns the dialect spec of d. Settings with their zero value are
+// left out.
+func (d Dialect) String() string {
+	var items []string
+	add := func(key, value string) {
+		items = append(items, key+"="+escapeSpec(value))
+	}
+	addRune := func(key string, r rune) {
+		if r != 0 {
+			add(key, string(r))
+		}
+	}
+	addRune("delim", d.Delimiter)
+	addRune("quote", d.QuoteChar)
+	addRune("escape", d.EscapeChar)
+	switch d.DoubleQuote {
+	case DoDoubleQuote:
+		add("doublequote", "true")
+	case NoDoubleQuote:
+		add("doublequote", "false")
+	}
+	if d.Quoting != QuoteDefault {
+		add("quoting", d.Quoting.String())
+	}
+	addRune("decimal", d.NumberFormat.Decimal)
+	addRune("grouping", d.NumberFormat.Grouping)
+	if d.LineTerminator != "" {
+		add("lt", d.LineTerminator)
+	}
+	if d.Comment == NoComment {
+		add("comment", "")
+	} else {
+		addRune("comment", d.Comment)
+	}
+	if d.SkipInitialSpace {
+		add("skipinitialspace", "true")
+	}
+	if d.Encoding != UTF8 {
+		add("encoding", d.Encoding.String())
+	}
+	if d.OnInvalid != InvalidReplace {
+		add("oninvalid", d.OnInvalid.String())
+	}
+	if d.FormulaEscape != FormulaEscapeNone {
+		add("formulaescape", d.FormulaEscape.String())
+	}
+	return strings.Join(items, ";")
+}
+
+// Set implements flag.Value by parsing a dialect spec.
+func (d *Dialect) Set(spec string) error {
+	parsed, err := ParseDialect(spec)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler. It fails if a setting has an
+// unknown value.
+func (d Dialect) MarshalText() ([]byte, error) {
+	if _, err := d.Quoting.MarshalText(); err != nil {
+		return nil, err
+	}
+	if _, err := d.Encoding.MarshalText(); err != nil {
+		return nil, err
+	}
+	if _, err := d.OnInvalid.MarshalText(); err != nil {
+		return nil, err
+	}
+	if _, err := d.FormulaEscape.MarshalText(); err != nil {
+		return nil, err
+	}
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler by parsing a dialect spec.
+func (d *Dialect) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
+
+// MarshalJSON implements json.Marshaler. A Dialect is a JSON string holding
+// its spec.
+func (d Dialect) MarshalJSON() ([]byte, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Dialect) UnmarshalJSON(data []byte) error {
+	var spec string
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+	return d.Set(spec)
+}
+
+func specRune(value string) (rune, error) {
+	if utf8.RuneCountInString(value) != 1 {
+		return 0, fmt.Errorf("%q is not a single character", value)
+	}
+	r, _ := utf8.DecodeRuneInString(value)
+	return r, nil
+}
+
+// unescapeSpec decodes the escape sequences in a dialect spec value.
+func unescapeSpec(s string) (string, error) {
+	var b strings.Builder
+	for len(s) > 0 {
+		if len(s) > 1 && s[0] == '\\' && strings.IndexByte(`\trnxuU`, s[1]) >= 0 {
+			r, _, tail, err := strconv.UnquoteChar(s, 0)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence in %q", s)
+			}
+			b.WriteRune(r)
+			s = tail
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		b.WriteRune(r)
+		s = s[size:]
+	}
+	return b.String(), nil
+}
+
+// escapeSpec encodes a dialect spec value.
+func escapeSpec(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == ';':
+			b.WriteString(`\x3b`)
+		case unicode.IsPrint(r):
+			b.WriteRune(r)
+		case r < utf8.RuneSelf:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r <= 0xFFFF:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			fmt.Fprintf(&b, `\U%08x`, r)
+		}
+	}
+	return b.String()
+}
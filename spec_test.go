@@ -0,0 +1,156 @@
+// Copyright 2014 Jens Rantil. All rights reserved.  Use of this source code is
+// governed by a BSD-style license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseDialect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec     string
+		expected Dialect
+	}{
+		{"", Dialect{}},
+		{
+			`delim=\t;quote=";escape=\;quoting=minimal;lt=\r\n`,
+			Dialect{Delimiter: '\t', QuoteChar: '"', EscapeChar: '\\', Quoting: QuoteMinimal, LineTerminator: "\r\n"},
+		},
+		{
+			`delim=\x3b; doublequote=false;comment=\\`,
+			Dialect{Delimiter: ';', DoubleQuote: NoDoubleQuote, Comment: '\\'},
+		},
+		{
+			`encoding=latin1;oninvalid=error;formulaescape=prefix;delim=\u00a7`,
+			Dialect{Encoding: Latin1, OnInvalid: InvalidError, FormulaEscape: FormulaEscapePrefix, Delimiter: '§'},
+		},
+		{"excel", ExcelDialect},
+		{`excel;delim=\t`, ExcelTabDialect},
+		{"unix;quoting=nonnumeric", Dialect{Delimiter: ',', QuoteChar: '"', DoubleQuote: DoDoubleQuote, Quoting: QuoteNonNumeric, LineTerminator: "\n", Comment: NoComment}},
+		{"comment=", Dialect{Comment: NoComment}},
+		{"decimal=,;grouping=.", Dialect{NumberFormat: NumberFormat{Decimal: ',', Grouping: '.'}}},
+		{"grouping=", Dialect{}},
+	}
+	for _, test := range tests {
+		d, err := ParseDialect(test.spec)
+		if err != nil {
+			t.Error(test.spec, "Unexpected error:", err)
+			continue
+		}
+		if !reflect.DeepEqual(d, test.expected) {
+			t.Error(test.spec, "Unexpected output:", d, "Expected:", test.expected)
+		}
+	}
+
+	invalid := []string{
+		"delim",
+		"delim=,;quote",
+		"nosuchdialect",
+		"delim=ab",
+		`lt=\x4`,
+		"quoting=sometimes",
+		"columnquoting=all",
+		"doublequote=maybe",
+		"decimal=",
+		"formulaexempt=1",
+		"color=red",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseDialect(spec); err == nil {
+			t.Error(spec, "Expected an error.")
+		}
+	}
+}
+
+func TestDialectStringRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dialects := []Dialect{
+		{},
+		ExcelDialect,
+		{Delimiter: ';', EscapeChar: '\\', QuoteChar: '\x00', LineTerminator: "\r\n\x1e"},
+		{Delimiter: '\U0001F600', Comment: '\u2028', DoubleQuote: NoDoubleQuote, Quoting: QuoteAll},
+		{Quoting: QuoteNonNumericNonEmpty, Encoding: UTF16BE, OnInvalid: InvalidPassThrough, Comment: NoComment},
+		{FormulaEscape: FormulaEscapeQuotedPrefix, SkipInitialSpace: true},
+		{Quoting: QuoteNonNumeric, NumberFormat: NumberFormat{Decimal: ',', Grouping: '\u00a0'}},
+	}
+	for _, d := range dialects {
+		parsed, err := ParseDialect(d.String())
+		if err != nil {
+			t.Error(d.String(), "Unexpected error:", err)
+			continue
+		}
+		// Dialects must stay comparable.
+		if parsed != d {
+			t.Errorf("%q: Unexpected output: %#v Expected: %#v", d.String(), parsed, d)
+		}
+	}
+
+	expected := `delim=,;quote=";doublequote=true;quoting=minimal;lt=\r\n;comment=`
+	if s := ExcelDialect.String(); s != expected {
+		t.Error("Unexpected output:", s, "Expected:", expected)
+	}
+}
+
+func TestDialectFlag(t *testing.T) {
+	t.Parallel()
+
+	fset := flag.NewFlagSet("test", flag.ContinueOnError)
+	fset.SetOutput(io.Discard)
+	var input Dialect
+	output := UnixDialect
+	fset.Var(&input, "input-format", "input dialect")
+	fset.Var(&output, "output-format", "output dialect")
+	if err := fset.Parse([]string{"-input-format", `delim=\t;quote='`}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if expected := (Dialect{Delimiter: '\t', QuoteChar: '\''}); !reflect.DeepEqual(input, expected) {
+		t.Error("Unexpected output:", input, "Expected:", expected)
+	}
+	if !reflect.DeepEqual(output, UnixDialect) {
+		t.Error("Unexpected output:", output, "Expected:", UnixDialect)
+	}
+	if err := fset.Parse([]string{"-input-format", "delim="}); err == nil {
+		t.Error("Expected an error.")
+	}
+}
+
+func TestDialectJSON(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Input  Dialect
+		Output *Dialect
+	}
+	c := config{Input: ExcelTabDialect, Output: &Dialect{Delimiter: ';'}}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := `{"Input":"delim=\\t;quote=\";doublequote=true;quoting=minimal;lt=\\r\\n;comment=","Output":"delim=\\x3b"}`
+	if string(b) != expected {
+		t.Error("Unexpected output:", string(b), "Expected:", expected)
+	}
+
+	var decoded config
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !reflect.DeepEqual(decoded, c) {
+		t.Error("Unexpected output:", decoded, "Expected:", c)
+	}
+	if err := json.Unmarshal([]byte(`{"Input":"excel"}`), &decoded); err != nil || !reflect.DeepEqual(decoded.Input, ExcelDialect) {
+		t.Error("Unexpected output:", decoded.Input, err)
+	}
+
+	if _, err := json.Marshal(Dialect{Quoting: QuoteMode(42)}); err == nil {
+		t.Error("Expected an error.")
+	}
+}
@@ -0,0 +1,69 @@
+#!/usr/bin/env python3
+"""Generates expected.json by parsing every test vector with CPython's csv
+module.
+
+Run from this directory whenever a case is added:
+
+    python3 generate.py > expected.json
+"""
+
+import csv
+import json
+import sys
+
+RFC4180 = {"delimiter": ",", "quotechar": '"', "doublequote": True, "escapechar": None}
+CRLF = dict(RFC4180, lineterminator="\r\n")
+SEMICOLON = dict(RFC4180, delimiter=";")
+PIPE_SINGLE_QUOTE = dict(RFC4180, delimiter="|", quotechar="'")
+ESCAPED = dict(RFC4180, doublequote=False, escapechar="\\")
+MYSQL = dict(ESCAPED, delimiter="\t")
+
+# (name, input file, dialect)
+CASES = [
+    ("simple", "simple.csv", RFC4180),
+    ("simple_crlf", "simple_crlf.csv", CRLF),
+    ("comma_in_quotes", "comma_in_quotes.csv", RFC4180),
+    ("escaped_quotes", "escaped_quotes.csv", RFC4180),
+    ("newlines", "newlines.csv", RFC4180),
+    ("newlines_crlf", "newlines_crlf.csv", CRLF),
+    ("quotes_and_newlines", "quotes_and_newlines.csv", RFC4180),
+    ("utf8", "utf8.csv", RFC4180),
+    ("empty", "empty.csv", RFC4180),
+    ("empty_crlf", "empty_crlf.csv", CRLF),
+    ("json", "json.csv", RFC4180),
+    ("no_trailing_newline", "no_trailing_newline.csv", RFC4180),
+    ("empty_fields", "empty_fields.csv", RFC4180),
+    ("blank_lines", "blank_lines.csv", RFC4180),
+    ("comment", "comment.csv", RFC4180),
+    ("whitespace", "whitespace.csv", RFC4180),
+    ("quotes_in_unquoted", "quotes_in_unquoted.csv", RFC4180),
+    ("semicolon", "semicolon.csv", SEMICOLON),
+    ("single_quote", "single_quote.csv", PIPE_SINGLE_QUOTE),
+    ("escaped_tsv", "escaped_tsv.csv", MYSQL),
+    ("escape_in_quoted", "escape_in_quoted.csv", ESCAPED),
+    ("escape_in_quoted_doublequote", "escape_in_quoted.csv", RFC4180),
+    ("escape_in_unquoted", "escape_in_unquoted.csv", ESCAPED),
+    ("escape_before_other", "escape_before_other.csv", ESCAPED),
+    ("unterminated_quote", "unterminated_quote.csv", RFC4180),
+]
+
+
+def main():
+    expected = []
+    for name, filename, dialect in CASES:
+        dialect = dict(dialect)
+        dialect.setdefault("lineterminator", "\n")
+        with open(filename, newline="", encoding="utf-8") as f:
+            records = list(csv.reader(f, **{k: v for k, v in dialect.items() if k != "lineterminator"}))
+        expected.append({
+            "name": name,
+            "file": filename,
+            "dialect": dialect,
+            "records": records,
+        })
+    json.dump(expected, sys.stdout, indent=2, ensure_ascii=False)
+    sys.stdout.write("\n")
+
+
+if __name__ == "__main__":
+    main()
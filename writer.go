@@ -5,82 +5,189 @@ package csv
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // A Writer writes records to a CSV encoded file.
 //
 // Can be created by calling either NewWriter or using NewDialectWriter.
 type Writer struct {
-	opts Dialect
-	w    *bufio.Writer
+	// UseCRLF, if true, makes the Writer terminate records with "\r\n" instead
+	// of Dialect.LineTerminator. Newlines inside quoted fields are also written
+	// as "\r\n".
+	UseCRLF bool
+	// ByteOrderMark to write before the first record. Defaults to
+	// NoByteOrderMark.
+	ByteOrderMark ByteOrderMark
+	// Quoting mode to use for individual columns. The n:th element applies to
+	// the n:th field of every record. Columns beyond the end of the slice, or
+	// set to QuoteDefault, use Dialect.Quoting.
+	ColumnQuoting []QuoteMode
+	// QuoteFunc, if not nil, decides whether the field in the zero-based column
+	// should be quoted. Takes precedence over Dialect.Quoting and
+	// ColumnQuoting.
+	QuoteFunc func(column int, field string) bool
+	// IsNumeric, if not nil, decides whether a field is numeric when using
+	// QuoteNonNumeric or QuoteNonNumericNonEmpty. Defaults to
+	// Dialect.NumberFormat.IsNumeric. It is a Writer field rather than a
+	// Dialect one since a func would make Dialect impossible to compare, and
+	// to describe in dialect specs and flags.
+	IsNumeric func(field string) bool
+	// Zero-based columns Dialect.FormulaEscape does not apply to, for example
+	// numeric columns with negative numbers.
+	FormulaExemptColumns []int
+
+	opts    Dialect
+	out     io.Writer
+	w       *bufio.Writer
+	started bool
+	err     error
+	stats   WriterStats
+}
+
+// WriterStats holds counters of what a Writer has written so far.
+type WriterStats struct {
+	// Number of records written.
+	Records int64
+	// Number of bytes written, including bytes that have not been flushed yet.
+	// Counted before transcoding to Dialect.Encoding.
+	Bytes int64
+	// Number of fields that were quoted.
+	QuotedFields int64
 }
 
 // Create a writer that conforms to RFC 4180 and behaves identical as a
 // encoding/csv.Reader.
 //
 // See `Default*` constants for default dialect used.
-func NewWriter(w io.Writer) Writer {
+func NewWriter(w io.Writer) *Writer {
 	return NewDialectWriter(w, Dialect{})
 }
 
 // Create a custom CSV writer.
-func NewDialectWriter(w io.Writer, opts Dialect) Writer {
+func NewDialectWriter(w io.Writer, opts Dialect) *Writer {
 	opts.setDefaults()
-	return Writer{
+	out := w
+	if opts.Encoding != UTF8 {
+		w = newEncodingWriter(w, opts.Encoding, opts.OnInvalid)
+	}
+	return &Writer{
 		opts: opts,
+		out:  out,
 		w:    bufio.NewWriter(w),
 	}
 }
 
-// Error reports any error that has occurred during a previous Write or Flush.
-func (w Writer) Error() error {
-	_, err := w.w.Write(nil)
-	return err
+// Error reports the first error that has occurred during a previous Write or
+// Flush. Once an error has occurred, all subsequent writes return it.
+func (w *Writer) Error() error {
+	return w.err
+}
+
+// Stats returns counters of what has been written so far.
+func (w *Writer) Stats() WriterStats {
+	return w.stats
 }
 
 // Flush writes any buffered data to the underlying io.Writer.
 // To check if an error occurred during the Flush, call Error.
-func (w Writer) Flush() {
-	w.w.Flush()
+func (w *Writer) Flush() {
+	if err := w.w.Flush(); err != nil && w.err == nil {
+		w.err = err
+	}
 }
 
-// Helper function that ditches the first return value of w.w.WriteString().
+// Helper function that counts the bytes written by w.w.WriteString().
 // Simplifies code.
-func (w Writer) writeString(s string) error {
-	_, err := w.w.WriteString(s)
+func (w *Writer) writeString(s string) error {
+	n, err := w.w.WriteString(s)
+	w.stats.Bytes += int64(n)
 	return err
 }
 
-func (w Writer) writeDelimiter() error {
+func (w *Writer) writeDelimiter() error {
 	return w.writeRune(w.opts.Delimiter)
 }
 
-func (w Writer) fieldNeedsQuote(field string) bool {
-	switch w.opts.Quoting {
+func (w *Writer) columnQuoting(column int) QuoteMode {
+	if column < len(w.ColumnQuoting) && w.ColumnQuoting[column] != QuoteDefault {
+		return w.ColumnQuoting[column]
+	}
+	return w.opts.Quoting
+}
+
+func (w *Writer) isNumeric(field string) bool {
+	if w.IsNumeric != nil {
+		return w.IsNumeric(field)
+	}
+	return w.opts.NumberFormat.IsNumeric(field)
+}
+
+func (w *Writer) fieldNeedsQuote(column int, field string) bool {
+	if w.QuoteFunc != nil {
+		return w.QuoteFunc(column, field)
+	}
+	switch w.columnQuoting(column) {
 	case QuoteNone:
 		return false
 	case QuoteAll:
 		return true
 	case QuoteNonNumeric:
-		return !isNumeric(field)
+		return !w.isNumeric(field) || w.fieldNeedsMinimalQuote(field)
 	case QuoteNonNumericNonEmpty:
-		return !(isNumeric(field) || isEmpty(field))
+		if isEmpty(field) {
+			return false
+		}
+		return !w.isNumeric(field) || w.fieldNeedsMinimalQuote(field)
 	case QuoteMinimal:
-		// TODO: Can be improved by making a single search with trie.
-		// See https://docs.python.org/2/library/csv.html#csv.QUOTE_MINIMAL for info on this.
-		return strings.Contains(field, w.opts.LineTerminator) || strings.ContainsRune(field, w.opts.Delimiter) || strings.ContainsRune(field, w.opts.QuoteChar)
+		return w.fieldNeedsMinimalQuote(field)
 	}
 	panic("Unexpected quoting.")
 }
 
-func (w Writer) writeRune(r rune) error {
-	_, err := w.w.WriteRune(r)
+// fieldNeedsMinimalQuote returns whether a field must be quoted for a Reader
+// to read it back unchanged. This is the case if it contains the delimiter,
+// quote character, any line terminator or carriage return/newline
+// character, or the escape character when it is in use. Fields starting with
+// the comment character or a byte order mark, and fields with leading or
+// trailing whitespace, are also quoted since they otherwise could be mistaken
+// for comments, skipped or trimmed.
+//
+// See https://docs.python.org/2/library/csv.html#csv.QUOTE_MINIMAL for info on this.
+func (w *Writer) fieldNeedsMinimalQuote(field string) bool {
+	if field == "" {
+		return false
+	}
+	// TODO: Can be improved by making a single search with trie.
+	if strings.ContainsRune(field, w.opts.Delimiter) || strings.ContainsRune(field, w.opts.QuoteChar) {
+		return true
+	}
+	if strings.ContainsAny(field, "\r\n") || strings.ContainsAny(field, w.opts.LineTerminator) {
+		return true
+	}
+	if w.opts.DoubleQuote == NoDoubleQuote && strings.ContainsRune(field, w.opts.EscapeChar) {
+		return true
+	}
+	first, _ := utf8.DecodeRuneInString(field)
+	last, _ := utf8.DecodeLastRuneInString(field)
+	if first == w.opts.Comment || unicode.IsSpace(first) || unicode.IsSpace(last) {
+		return true
+	}
+	// Reader would mistake these for a byte order mark at the start of a file.
+	return first == byteOrderMark || strings.HasPrefix(field, "\xff\xfe") || strings.HasPrefix(field, "\xfe\xff")
+}
+
+func (w *Writer) writeRune(r rune) error {
+	n, err := w.w.WriteRune(r)
+	w.stats.Bytes += int64(n)
 	return err
 }
 
-func (w Writer) writeEscapeChar(r rune) error {
+func (w *Writer) writeEscapeChar(r rune) error {
 	switch w.opts.DoubleQuote {
 	case DoDoubleQuote:
 		return w.writeRune(r)
@@ -90,13 +197,13 @@ func (w Writer) writeEscapeChar(r rune) error {
 	panic("Unrecognized double quote type.")
 }
 
-func (w Writer) writeQuotedRune(r rune) error {
-	switch r {
-	case w.opts.EscapeChar:
+func (w *Writer) writeQuotedRune(r rune) error {
+	switch {
+	case r == w.opts.EscapeChar && w.opts.DoubleQuote == NoDoubleQuote:
 		if err := w.writeEscapeChar(r); err != nil {
 			return err
 		}
-	case w.opts.QuoteChar:
+	case r == w.opts.QuoteChar:
 		if err := w.writeEscapeChar(r); err != nil {
 			return err
 		}
@@ -104,52 +211,125 @@ func (w Writer) writeQuotedRune(r rune) error {
 	return w.writeRune(r)
 }
 
-func (w Writer) writeQuoted(field string) error {
+func (w *Writer) writeQuoted(field string) error {
+	w.stats.QuotedFields++
 	if err := w.writeRune(w.opts.QuoteChar); err != nil {
 		return err
 	}
-	for _, r := range field {
-		if err := w.writeQuotedRune(r); err != nil {
+	if w.UseCRLF {
+		field = strings.Replace(strings.Replace(field, "\r\n", "\n", -1), "\n", "\r\n", -1)
+	}
+	for len(field) > 0 {
+		r, size := utf8.DecodeRuneInString(field)
+		var err error
+		if r == utf8.RuneError && size == 1 {
+			// Invalid UTF-8 is written as is, like in unquoted fields.
+			err = w.writeString(field[:1])
+		} else {
+			err = w.writeQuotedRune(r)
+		}
+		if err != nil {
 			return err
 		}
+		field = field[size:]
 	}
 	return w.writeRune(w.opts.QuoteChar)
 }
 
-func (w Writer) writeField(field string) error {
-	if w.fieldNeedsQuote(field) {
+func (w *Writer) writeField(column int, field string) error {
+	field, forceQuote := w.opts.escapeFormula(w.FormulaExemptColumns, column, field)
+	if forceQuote || w.fieldNeedsQuote(column, field) {
 		return w.writeQuoted(field)
 	}
 	return w.writeString(field)
 }
 
-func (w Writer) writeNewline() error {
+func (w *Writer) writeNewline() error {
+	if w.UseCRLF {
+		return w.writeString("\r\n")
+	}
 	return w.writeString(w.opts.LineTerminator)
 }
 
+// start is called before the first record is written.
+func (w *Writer) start() error {
+	w.started = true
+	switch w.ByteOrderMark {
+	case NoByteOrderMark:
+		return nil
+	case UTF8ByteOrderMark:
+		if w.opts.Encoding != UTF8 {
+			// Would label output in another encoding as UTF-8.
+			return fmt.Errorf("csv: can't write a UTF-8 byte order mark in %v", w.opts.Encoding)
+		}
+	case UTF16LEByteOrderMark:
+		switch w.opts.Encoding {
+		case UTF16LE:
+		case UTF8:
+			// Flushing keeps anything already buffered in UTF-8.
+			if err := w.w.Flush(); err != nil {
+				return err
+			}
+			w.w = bufio.NewWriter(newEncodingWriter(w.out, UTF16LE, w.opts.OnInvalid))
+		default:
+			// Would label output in another encoding as UTF-16LE.
+			return fmt.Errorf("csv: can't write a UTF-16LE byte order mark in %v", w.opts.Encoding)
+		}
+	default:
+		panic("Unrecognized byte order mark.")
+	}
+	return w.writeRune(byteOrderMark)
+}
+
 // Writer writes a single CSV record to w along with any necessary quoting.
 // A record is a slice of strings with each string being one field.
-func (w Writer) Write(record []string) (err error) {
+func (w *Writer) Write(record []string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if !w.started {
+		if err := w.start(); err != nil {
+			w.err = err
+			return err
+		}
+	}
+	if err := w.writeRecord(record); err != nil {
+		w.err = err
+		return err
+	}
+	w.stats.Records++
+	return nil
+}
+
+func (w *Writer) writeRecord(record []string) error {
+	// A record with a single empty field would otherwise be written as an empty
+	// line, which many readers skip.
+	if len(record) == 1 && record[0] == "" && w.QuoteFunc == nil && w.columnQuoting(0) != QuoteNone {
+		if err := w.writeQuoted(""); err != nil {
+			return err
+		}
+		return w.writeNewline()
+	}
 	for n, field := range record {
 		if n > 0 {
-			if err = w.writeDelimiter(); err != nil {
-				return
+			if err := w.writeDelimiter(); err != nil {
+				return err
 			}
 		}
-		if err = w.writeField(field); err != nil {
-			return
+		if err := w.writeField(n, field); err != nil {
+			return err
 		}
 	}
-	err = w.writeNewline()
-	return
+	return w.writeNewline()
 }
 
 // WriteAll writes multiple CSV records to w using Write and then calls Flush.
-func (w Writer) WriteAll(records [][]string) (err error) {
+func (w *Writer) WriteAll(records [][]string) error {
 	for _, record := range records {
 		if err := w.Write(record); err != nil {
 			return err
 		}
 	}
-	return w.w.Flush()
+	w.Flush()
+	return w.err
 }
@@ -5,6 +5,8 @@ package csv
 
 import (
 	"bytes"
+	"errors"
+	"reflect"
 	"testing"
 	"testing/quick"
 )
@@ -87,7 +89,7 @@ func TestMinimalQuoting(t *testing.T) {
 	if w.opts.Quoting != QuoteMinimal {
 		t.Fatal("Unexpected quoting.")
 	}
-	if s := "b,c"; !w.fieldNeedsQuote(s) {
+	if s := "b,c"; !w.fieldNeedsQuote(0, s) {
 		t.Error("Expected field to need quoting:", s)
 	}
 
@@ -102,6 +104,65 @@ func TestMinimalQuoting(t *testing.T) {
 	}
 }
 
+func TestMinimalQuotingRoundTripSafe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dialect  Dialect
+		record   []string
+		expected string
+	}{
+		{Dialect{}, []string{"a\rb"}, "\"a\rb\"\n"},
+		{Dialect{LineTerminator: "\r\n"}, []string{"a\nb"}, "\"a\nb\"\r\n"},
+		{Dialect{}, []string{" a", "b "}, "\" a\",\"b \"\n"},
+		{Dialect{}, []string{"#a", "#b"}, "\"#a\",\"#b\"\n"},
+		{Dialect{}, []string{""}, "\"\"\n"},
+		{Dialect{}, []string{"", ""}, ",\n"},
+		{Dialect{}, []string{"a\\b"}, "a\\b\n"},
+		{Dialect{DoubleQuote: NoDoubleQuote}, []string{"a\\b"}, "\"a\\\\b\"\n"},
+		{Dialect{Delimiter: '.', Quoting: QuoteNonNumeric}, []string{"1.5", "2"}, "\"1.5\".2\n"},
+	}
+	for _, test := range tests {
+		b := new(bytes.Buffer)
+		w := NewDialectWriter(b, test.dialect)
+		w.Write(test.record)
+		w.Flush()
+		if s := b.String(); s != test.expected {
+			t.Errorf("Unexpected output for %q: %q Expected: %q", test.record, s, test.expected)
+		}
+	}
+}
+
+func FuzzMinimalQuotingRoundTrip(f *testing.F) {
+	f.Add("a\x1fb\x1ec", false)
+	f.Add("", false)
+	f.Add(" #a\x1f\"b\"\x1e\r\x1f\n", true)
+	f.Add("\\\"\x1f\\", false)
+	f.Fuzz(func(t *testing.T, data string, doubleQuote bool) {
+		records := fuzzRecords(data)
+		for _, lt := range []string{"\n", "\r\n"} {
+			dialect := Dialect{LineTerminator: lt, DoubleQuote: NoDoubleQuote, OnInvalid: InvalidPassThrough}
+			if doubleQuote {
+				dialect.DoubleQuote = DoDoubleQuote
+			}
+
+			b := new(bytes.Buffer)
+			w := NewDialectWriter(b, dialect)
+			if err := w.WriteAll(records); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			written := b.String()
+			read, err := NewDialectReader(b, dialect).ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if !reflect.DeepEqual(records, read) {
+				t.Errorf("Round trip failed.\nwritten=%q\nread=%q\nexpected=%q", written, read, records)
+			}
+		}
+	})
+}
+
 func TestNumericQuoting(t *testing.T) {
 	t.Parallel()
 
@@ -121,6 +182,97 @@ func TestNumericQuoting(t *testing.T) {
 	}
 }
 
+func TestNumericQuotingSignsAndExponents(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	dialect := Dialect{
+		Quoting: QuoteNonNumeric,
+	}
+	w := NewDialectWriter(b, dialect)
+	w.Write([]string{
+		"-5",
+		"1e10",
+		"1.2.3",
+		"...",
+	})
+	w.Flush()
+	if s, expected := b.String(), "-5,1e10,\"1.2.3\",\"...\"\n"; s != expected {
+		t.Error("Unexpected output:", s, "Expected:", expected)
+	}
+}
+
+func TestCustomNumericQuoting(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	w := NewDialectWriter(b, Dialect{Delimiter: ';', Quoting: QuoteNonNumeric, NumberFormat: NumberFormat{Decimal: ',', Grouping: '.'}})
+	w.Write([]string{
+		"1.000,5",
+		"1.5",
+	})
+	w.Flush()
+	if s, expected := b.String(), "1.000,5;\"1.5\"\n"; s != expected {
+		t.Error("Unexpected output:", s, "Expected:", expected)
+	}
+
+	b.Reset()
+	w = NewDialectWriter(b, Dialect{Quoting: QuoteNonNumeric})
+	w.IsNumeric = func(field string) bool { return field == "n/a" || IsNumeric(field) }
+	w.Write([]string{
+		"n/a",
+		"1.5",
+		"a",
+	})
+	w.Flush()
+	if s, expected := b.String(), "n/a,1.5,\"a\"\n"; s != expected {
+		t.Error("Unexpected output:", s, "Expected:", expected)
+	}
+}
+
+func TestColumnQuoting(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	w := NewDialectWriter(b, Dialect{Quoting: QuoteNone})
+	w.ColumnQuoting = []QuoteMode{QuoteAll, QuoteDefault, QuoteNonNumeric}
+	w.Write([]string{
+		"01234",
+		"a",
+		"b",
+		"c",
+	})
+	w.Write([]string{
+		"",
+		"1",
+		"2",
+	})
+	w.Flush()
+	if s, expected := b.String(), "\"01234\",a,\"b\",c\n\"\",1,2\n"; s != expected {
+		t.Error("Unexpected output:", s, "Expected:", expected)
+	}
+}
+
+func TestQuoteFunc(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	w := NewDialectWriter(b, Dialect{Quoting: QuoteAll})
+	w.ColumnQuoting = []QuoteMode{QuoteAll}
+	w.QuoteFunc = func(column int, field string) bool {
+		return column == 1 || field == "x"
+	}
+	w.Write([]string{
+		"a",
+		"b",
+		"x",
+	})
+	w.Flush()
+	if s, expected := b.String(), "a,\"b\",\"x\"\n"; s != expected {
+		t.Error("Unexpected output:", s, "Expected:", expected)
+	}
+}
+
 func TestEmptyFieldQuoting(t *testing.T) {
 	t.Parallel()
 
@@ -187,3 +339,154 @@ func TestNewLineRecord(t *testing.T) {
 		t.Error("Unexpected output:", s, "Expected:", expected)
 	}
 }
+
+// A writer that fails after having written a number of bytes.
+type failingWriter struct {
+	n int
+}
+
+var errFailingWriter = errors.New("failing writer")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errFailingWriter
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestStickyError(t *testing.T) {
+	t.Parallel()
+
+	w := NewWriter(&failingWriter{n: 2})
+	if err := w.Write([]string{"a", "b"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := w.Error(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != errFailingWriter {
+		t.Fatal("Expected flush error, got:", err)
+	}
+	if err := w.Write([]string{"c"}); err != errFailingWriter {
+		t.Error("Expected sticky error, got:", err)
+	}
+	if err := w.WriteAll([][]string{{"d"}}); err != errFailingWriter {
+		t.Error("Expected sticky error, got:", err)
+	}
+	if records := w.Stats().Records; records != 1 {
+		t.Error("Unexpected number of records:", records)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	w := NewWriter(b)
+	w.Write([]string{"a", "b,c"})
+	w.Write([]string{"\"", "å"})
+	w.Write([]string{""})
+	w.Flush()
+
+	expected := WriterStats{
+		Records:      3,
+		Bytes:        int64(b.Len()),
+		QuotedFields: 3,
+	}
+	if stats := w.Stats(); stats != expected {
+		t.Errorf("Unexpected stats: %+v Expected: %+v", stats, expected)
+	}
+}
+
+func TestUseCRLF(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	w := NewWriter(b)
+	w.UseCRLF = true
+	w.Write([]string{"a\nb", "c\r\nd", "e\rf"})
+	w.Flush()
+	if s, expected := b.String(), "\"a\r\nb\",\"c\r\nd\",\"e\rf\"\r\n"; s != expected {
+		t.Errorf("Unexpected output: %q Expected: %q", s, expected)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	w := NewWriter(b)
+	w.ByteOrderMark = UTF8ByteOrderMark
+	w.Write([]string{"a", "b"})
+	w.Write([]string{"c", "d"})
+	w.Flush()
+	if s, expected := b.String(), "\xef\xbb\xbfa,b\nc,d\n"; s != expected {
+		t.Errorf("Unexpected output: %q Expected: %q", s, expected)
+	}
+
+	b.Reset()
+	w = NewWriter(b)
+	w.ByteOrderMark = UTF16LEByteOrderMark
+	w.Write([]string{"å"})
+	w.Flush()
+	if s, expected := b.String(), "\xff\xfe\xe5\x00\n\x00"; s != expected {
+		t.Errorf("Unexpected output: %q Expected: %q", s, expected)
+	}
+
+	b.Reset()
+	w = NewDialectWriter(b, Dialect{Encoding: UTF16LE})
+	w.ByteOrderMark = UTF16LEByteOrderMark
+	w.Write([]string{"å"})
+	w.Flush()
+	if s, expected := b.String(), "\xff\xfe\xe5\x00\n\x00"; s != expected {
+		t.Errorf("Unexpected output: %q Expected: %q", s, expected)
+	}
+
+	for _, encoding := range []Encoding{Windows1252, UTF16BE} {
+		b.Reset()
+		w = NewDialectWriter(b, Dialect{Encoding: encoding})
+		w.ByteOrderMark = UTF16LEByteOrderMark
+		if err := w.Write([]string{"a"}); err == nil {
+			t.Error("Expected an error for a UTF-16LE byte order mark in", encoding)
+		}
+		w.Flush()
+		if s := b.String(); s != "" {
+			t.Errorf("Unexpected output: %q Expected: %q", s, "")
+		}
+	}
+
+	b.Reset()
+	w = NewDialectWriter(b, Dialect{Encoding: Latin1})
+	w.ByteOrderMark = UTF8ByteOrderMark
+	if err := w.Write([]string{"a"}); err == nil {
+		t.Error("Expected an error for a UTF-8 byte order mark in Latin1.")
+	}
+	w.Flush()
+	if s := b.String(); s != "" {
+		t.Errorf("Unexpected output: %q Expected: %q", s, "")
+	}
+}
+
+func TestWritingEncoding(t *testing.T) {
+	t.Parallel()
+
+	b := new(bytes.Buffer)
+	w := NewDialectWriter(b, Dialect{Encoding: Windows1252})
+	w.Write([]string{"café", "€"})
+	w.Flush()
+	if s, expected := b.String(), "caf\xe9,\x80\n"; s != expected {
+		t.Errorf("Unexpected output: %q Expected: %q", s, expected)
+	}
+
+	b.Reset()
+	w = NewDialectWriter(b, Dialect{Encoding: Latin1, OnInvalid: InvalidError})
+	if err := w.WriteAll([][]string{{"€"}}); err == nil {
+		t.Error("Expected an error.")
+	} else if _, ok := err.(*EncodingError); !ok {
+		t.Error("Unexpected error:", err)
+	}
+}